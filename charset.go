@@ -0,0 +1,125 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// charsetSniffSize is the number of bytes examined by [Options.DetectCharset].
+const charsetSniffSize = 4096
+
+// decode returns src transcoded to UTF-8
+// as set by o.Charset and o.DetectCharset,
+// or from UTF-16 if src begins with a UTF-16 byte order mark,
+// and without a byte order mark at its start.
+func (o *Options) decode(src io.Reader) (io.Reader, error) {
+	enc := o.Charset
+	if enc == nil {
+		var (
+			isUTF16 bool
+			err     error
+		)
+		src, isUTF16, err = sniffBOM(src)
+		if err != nil {
+			return nil, err
+		}
+		if isUTF16 {
+			enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+		}
+	}
+	if enc == nil && o.DetectCharset {
+		br := newBufioReader(src, o.BufferSize)
+		src = br
+		head, err := br.Peek(min(charsetSniffSize, br.Size()))
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+		if e, ok := utf16Endianness(head); ok {
+			enc = unicode.UTF16(e, unicode.IgnoreBOM)
+		} else if validUTF8Prefix(head) {
+			return src, nil
+		}
+		if enc == nil {
+			enc = charmap.Windows1252
+		}
+	}
+	if enc == nil {
+		return src, nil
+	}
+	src, _, err := sniffBOM(transform.NewReader(src, enc.NewDecoder()))
+	return src, err
+}
+
+// sniffBOM drops a UTF-8 byte order mark from the start of src,
+// and reports whether src instead begins with a UTF-16 byte order mark,
+// which is left for the decoder.
+func sniffBOM(src io.Reader) (io.Reader, bool, error) {
+	if mr, ok := src.(*memReader); ok {
+		// Leave the data in place for the parser.
+		data := mr.data[len(mr.data)-mr.Len():]
+		if bytes.HasPrefix(data, utf8BOM) {
+			mr.Seek(int64(len(utf8BOM)), io.SeekCurrent)
+		}
+		return mr, isUTF16BOM(data), nil
+	}
+	head := make([]byte, len(utf8BOM))
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, false, err
+	}
+	head = head[:n]
+	if bytes.Equal(head, utf8BOM) {
+		return src, false, nil
+	}
+	return io.MultiReader(bytes.NewReader(head), src), isUTF16BOM(head), nil
+}
+
+// isUTF16BOM reports whether b begins with a UTF-16 byte order mark.
+func isUTF16BOM(b []byte) bool {
+	return bytes.HasPrefix(b, []byte{0xFF, 0xFE}) || bytes.HasPrefix(b, []byte{0xFE, 0xFF})
+}
+
+// utf8BOM is the UTF-8 encoding of the byte order mark.
+var utf8BOM = []byte("\ufeff")
+
+// validUTF8Prefix reports whether b is valid UTF-8,
+// except perhaps for an incomplete rune at its end.
+func validUTF8Prefix(b []byte) bool {
+	for cut := 0; cut < utf8.UTFMax && cut <= len(b); cut++ {
+		if utf8.Valid(b[:len(b)-cut]) {
+			return cut == 0 || !utf8.FullRune(b[len(b)-cut:])
+		}
+	}
+	return false
+}
+
+// utf16Endianness guesses whether b is UTF-16 without a byte order mark
+// from the zero bytes that make up the high half of ASCII characters.
+func utf16Endianness(b []byte) (unicode.Endianness, bool) {
+	var even, odd int
+	for i, c := range b {
+		if c != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			even++
+		} else {
+			odd++
+		}
+	}
+	pairs := len(b) / 2
+	switch {
+	case pairs == 0:
+	case odd > pairs/4 && even == 0:
+		return unicode.LittleEndian, true
+	case even > pairs/4 && odd == 0:
+		return unicode.BigEndian, true
+	}
+	return unicode.LittleEndian, false
+}
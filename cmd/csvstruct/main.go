@@ -0,0 +1,91 @@
+// Command csvstruct generates a Go struct type for the header of a CSV file,
+// with fields tagged for use with csv.Row.Scan.
+// The fields are strings, unless the -infer flag is set to a number of rows
+// from which to infer their types, or -1 to infer them from every row.
+//
+// Usage:
+//
+//	csvstruct [-comma c] [-infer n] [-o output.go] -pkg name -type Name input.csv
+//
+// For example, in a Go source file:
+//
+//	//go:generate go run github.com/earthboundkid/csv/v2/cmd/csvstruct -pkg users -type User -o user.go users.csv
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"unicode/utf8"
+
+	"github.com/earthboundkid/csv/v2"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "csvstruct:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	comma := flag.String("comma", ",", "field `delimiter`")
+	out := flag.String("o", "", "output `file` (default standard output)")
+	pkg := flag.String("pkg", "", "package `name` of the generated file")
+	typeName := flag.String("type", "", "`name` of the generated type")
+	infer := flag.Int("infer", 0, "infer field types from the first `n` rows, or every row if -1")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: csvstruct [flags] input.csv")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *pkg == "" || *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c, size := utf8.DecodeRuneInString(*comma)
+	if size != len(*comma) {
+		return fmt.Errorf("invalid delimiter %q", *comma)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	o := csv.Options{
+		Reader: f,
+		Comma:  c,
+	}
+	var src []byte
+	if *infer != 0 {
+		var s csv.Schema
+		if s, err = csv.InferSchema(o, *infer); err == nil {
+			src, err = s.GenerateStruct(*typeName)
+		}
+	} else {
+		src, err = csv.GenerateStruct(o, *typeName)
+	}
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by csvstruct from %s. DO NOT EDIT.\n\n", flag.Arg(0))
+	fmt.Fprintf(&buf, "package %s\n\n", *pkg)
+	if bytes.Contains(src, []byte(" time.Time ")) || bytes.Contains(src, []byte(" *time.Time ")) {
+		buf.WriteString("import \"time\"\n\n")
+	}
+	buf.Write(src)
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(*out, b, 0o644)
+}
@@ -0,0 +1,79 @@
+package csv
+
+import (
+	"io"
+	"iter"
+	"slices"
+)
+
+// Concat returns a sequence of the rows of each of sources in turn.
+// The header of every source is read before the first row is yielded,
+// and the rows are given the union of their fieldnames,
+// in the order each fieldname first appears.
+// Fieldnames missing from a source are filled with empty strings.
+// [Row.Number] counts rows across all sources, and [Row.Line] is the
+// line in the row's own source.
+func Concat(sources ...Options) iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		readers := make([]*Reader, 0, len(sources))
+		var header []string
+		for _, o := range sources {
+			r := NewReader(o)
+			r.started = true
+			if err := r.init(); err != nil {
+				if err != io.EOF {
+					yield(nil, err)
+					return
+				}
+				continue
+			}
+			for _, name := range r.row.header {
+				if !slices.Contains(header, name) {
+					header = append(header, name)
+				}
+			}
+			readers = append(readers, r)
+		}
+
+		var out Row
+		out.index(header, KeepLastDuplicate)
+		var cols []int
+		var record []string
+		number := 0
+		for _, r := range readers {
+			cols = cols[:0]
+			for _, name := range header {
+				col, ok := r.row.idx[name]
+				if !ok {
+					col = -1
+				}
+				cols = append(cols, col)
+			}
+			for r.Next() {
+				row := r.Row()
+				number++
+				record = record[:0]
+				for _, col := range cols {
+					if row.blank {
+						break
+					}
+					v := ""
+					if col >= 0 {
+						v = row.at(col)
+					}
+					record = append(record, v)
+				}
+				out.load(record)
+				out.blank, out.number, out.line = row.blank, number, row.line
+				out.scanConf = row.scanConf
+				if !yield(&out, nil) {
+					return
+				}
+			}
+			if err := r.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+}
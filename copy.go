@@ -0,0 +1,272 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// ErrCopyEscape is returned by [CopyReader] for an invalid backslash escape.
+var ErrCopyEscape = errors.New("csv: invalid escape in COPY data")
+
+// CopyReader reads records in the text format of PostgreSQL's COPY command,
+// as written by COPY TO and psql's \copy: fields are separated by tabs,
+// special characters are escaped with backslashes, and \N is null.
+// It can be used as [Options.Records]. The format has no header,
+// so [Options.FieldNames] should usually be set.
+type CopyReader struct {
+	// Null is the value read for null fields. It is empty by default.
+	Null string
+	// Delimiter is the field delimiter. It is set to tab by default.
+	Delimiter byte
+
+	r      *bufio.Reader
+	line   int
+	record []string
+	buf    []byte
+}
+
+// NewCopyReader returns a CopyReader reading from r.
+func NewCopyReader(r io.Reader) *CopyReader {
+	return &CopyReader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next record.
+// A line consisting of `\.` marks the end of the data.
+// The returned slice is reused by the next call to Read.
+func (cr *CopyReader) Read() ([]string, error) {
+	line, err := cr.r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		cr.buf = append(cr.buf[:0], line...)
+		for err == bufio.ErrBufferFull {
+			line, err = cr.r.ReadSlice('\n')
+			cr.buf = append(cr.buf, line...)
+		}
+		line = cr.buf
+	}
+	if len(line) == 0 && err != nil {
+		return nil, err
+	}
+	cr.line++
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if string(line) == `\.` {
+		return nil, io.EOF
+	}
+	delim := cmp.Or(cr.Delimiter, '\t')
+	cr.record = cr.record[:0]
+	for {
+		i := indexDelim(line, delim)
+		if i < 0 {
+			i = len(line)
+		}
+		v, err := cr.unescape(line[:i])
+		if err != nil {
+			return nil, err
+		}
+		cr.record = append(cr.record, v)
+		if i == len(line) {
+			return cr.record, nil
+		}
+		line = line[i+1:]
+	}
+}
+
+// indexDelim returns the index of the first delim in line
+// that is not escaped with a backslash, or -1 if there is none.
+func indexDelim(line []byte, delim byte) int {
+	if i := bytes.IndexByte(line, delim); i < 0 || bytes.IndexByte(line[:i], '\\') < 0 {
+		return i
+	}
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++ // Skip the escaped character.
+		case delim:
+			return i
+		}
+	}
+	return -1
+}
+
+// FieldPos returns the line of the last record read,
+// so that [Row.Line] is accurate. The column is always 1.
+func (cr *CopyReader) FieldPos(field int) (line, column int) {
+	return cr.line, 1
+}
+
+func (cr *CopyReader) unescape(field []byte) (string, error) {
+	if string(field) == `\N` {
+		return cr.Null, nil
+	}
+	i := bytes.IndexByte(field, '\\')
+	if i < 0 {
+		return string(field), nil
+	}
+	out := slices.Clone(field[:i])
+	for i < len(field) {
+		c := field[i]
+		if c != '\\' {
+			out = append(out, c)
+			i++
+			continue
+		}
+		i++
+		if i == len(field) {
+			return "", cr.escapeError()
+		}
+		c = field[i]
+		i++
+		switch c {
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'v':
+			out = append(out, '\v')
+		case 'x':
+			// One or two hex digits.
+			j := i
+			for j < len(field) && j < i+2 && isHex(field[j]) {
+				j++
+			}
+			if j == i {
+				return "", cr.escapeError()
+			}
+			n, _ := strconv.ParseUint(string(field[i:j]), 16, 8)
+			out = append(out, byte(n))
+			i = j
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			// One to three octal digits.
+			j := i
+			for j < len(field) && j < i+2 && field[j] >= '0' && field[j] <= '7' {
+				j++
+			}
+			n, _ := strconv.ParseUint(string(field[i-1:j]), 8, 16)
+			out = append(out, byte(n))
+			i = j
+		default:
+			// Any other character stands for itself.
+			out = append(out, c)
+		}
+	}
+	return string(out), nil
+}
+
+func (cr *CopyReader) escapeError() error {
+	return &csv.ParseError{StartLine: cr.line, Line: cr.line, Column: 1, Err: ErrCopyEscape}
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// CopyWriter writes rows in the text format of PostgreSQL's COPY command,
+// suitable for COPY FROM and psql's \copy. See [CopyReader].
+// The exported fields must be set before the first call to a Write method.
+type CopyWriter struct {
+	// Writer must be set.
+	Writer io.Writer
+	// Delimiter is the field delimiter. It is set to tab by default.
+	Delimiter byte
+	// FieldNames are the names of the columns to write, in order.
+	// If FieldNames is left nil, it will be set to the header of
+	// the first row written.
+	FieldNames []string
+	// If Header is true, FieldNames are written as the first line,
+	// as with COPY's HEADER option.
+	Header bool
+	// If Nulls is true, empty fields are written as null (\N).
+	Nulls bool
+
+	bw          *bufio.Writer
+	wroteHeader bool
+	record      []string
+}
+
+// WriteRow writes the fields of row named by w.FieldNames.
+// Fields missing from row are written as empty strings.
+func (w *CopyWriter) WriteRow(row *Row) error {
+	if w.FieldNames == nil {
+		w.FieldNames = slices.Clone(row.Header())
+	}
+	w.record = w.record[:0]
+	for _, name := range w.FieldNames {
+		w.record = append(w.record, row.Field(name))
+	}
+	return w.WriteRecord(w.record)
+}
+
+// WriteRecord writes record as is, after writing the header if needed.
+func (w *CopyWriter) WriteRecord(record []string) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.write(record, w.Nulls)
+}
+
+func (w *CopyWriter) writeHeader() error {
+	if w.bw == nil {
+		w.bw = bufio.NewWriter(w.Writer)
+	}
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	if !w.Header || w.FieldNames == nil {
+		return nil
+	}
+	return w.write(w.FieldNames, false)
+}
+
+func (w *CopyWriter) write(record []string, nulls bool) error {
+	delim := cmp.Or(w.Delimiter, '\t')
+	for i, field := range record {
+		if i > 0 {
+			w.bw.WriteByte(delim)
+		}
+		if field == "" && nulls {
+			w.bw.WriteString(`\N`)
+			continue
+		}
+		for j := 0; j < len(field); j++ {
+			switch c := field[j]; c {
+			case '\\':
+				w.bw.WriteString(`\\`)
+			case '\n':
+				w.bw.WriteString(`\n`)
+			case '\r':
+				w.bw.WriteString(`\r`)
+			case '\t':
+				w.bw.WriteString(`\t`)
+			default:
+				if c == delim {
+					w.bw.WriteByte('\\')
+				}
+				w.bw.WriteByte(c)
+			}
+		}
+	}
+	return w.bw.WriteByte('\n')
+}
+
+// Close writes the header if needed and flushes any buffered data
+// to the underlying io.Writer.
+// It does not close the underlying io.Writer.
+func (w *CopyWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.bw.Flush()
+}
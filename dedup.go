@@ -0,0 +1,92 @@
+package csv
+
+import (
+	"hash/maphash"
+	"math"
+)
+
+// Dedup returns a Transform that drops rows whose values of keyCols
+// match those of an earlier row. If keyCols is nil, whole rows are compared.
+// The keys of every distinct row are kept in memory;
+// for sources with too many distinct keys, see [DedupApprox].
+// The Transform keeps state between rows,
+// so it must not be shared between concurrent pipelines.
+func Dedup(keyCols []string) Transform {
+	seen := make(map[string]struct{})
+	var key []byte
+	return func(row *Row) (*Row, error) {
+		key = appendRowKey(key[:0], row, keyCols)
+		if _, ok := seen[string(key)]; ok {
+			return nil, nil
+		}
+		seen[string(key)] = struct{}{}
+		return row, nil
+	}
+}
+
+// DedupApprox is like [Dedup], but it uses a Bloom filter sized for n
+// distinct keys instead of keeping every key in memory.
+// A row that is not a duplicate is wrongly dropped with a probability
+// of about falsePositiveRate, as long as there are no more than n
+// distinct keys. Duplicates are always dropped.
+func DedupApprox(keyCols []string, n int, falsePositiveRate float64) Transform {
+	f := newBloomFilter(n, falsePositiveRate)
+	var key []byte
+	return func(row *Row) (*Row, error) {
+		key = appendRowKey(key[:0], row, keyCols)
+		if !f.add(key) {
+			return nil, nil
+		}
+		return row, nil
+	}
+}
+
+// appendRowKey appends a key made of the values of cols in row to dst,
+// or of all of the values of row if cols is nil.
+func appendRowKey(dst []byte, row *Row, cols []string) []byte {
+	if cols == nil {
+		return appendKey(dst, row, row.Header())
+	}
+	return appendKey(dst, row, cols)
+}
+
+// bloomFilter is a Bloom filter using double hashing.
+type bloomFilter struct {
+	bits         []uint64
+	m            uint64
+	k            int
+	seed1, seed2 maphash.Seed
+}
+
+func newBloomFilter(n int, p float64) *bloomFilter {
+	n = max(n, 1)
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := max(int(math.Round(float64(m)/float64(n)*math.Ln2)), 1)
+	return &bloomFilter{
+		bits:  make([]uint64, (m+63)/64),
+		m:     m,
+		k:     k,
+		seed1: maphash.MakeSeed(),
+		seed2: maphash.MakeSeed(),
+	}
+}
+
+// add adds key to f and reports whether it was not already present.
+func (f *bloomFilter) add(key []byte) bool {
+	h1 := maphash.Bytes(f.seed1, key)
+	h2 := maphash.Bytes(f.seed2, key) | 1
+	added := false
+	for i := range f.k {
+		bit := (h1 + uint64(i)*h2) % f.m
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if f.bits[word]&mask == 0 {
+			f.bits[word] |= mask
+			added = true
+		}
+	}
+	return added
+}
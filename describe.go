@@ -0,0 +1,140 @@
+package csv
+
+import (
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ColumnStats summarizes the values of one column, as found by [Describe].
+type ColumnStats struct {
+	// Name is the fieldname of the column.
+	Name string
+	// Count is the number of non-empty values.
+	Count int
+	// Distinct is an estimate of the number of distinct non-empty values.
+	// It is usually within 2% of the true number.
+	Distinct int
+	// Numeric reports whether every non-empty value is a number.
+	// Min, Max, and Mean are only set if Numeric is true.
+	Numeric        bool
+	Min, Max, Mean float64
+	// MinLen and MaxLen are the least and greatest number of characters
+	// in a non-empty value.
+	MinLen, MaxLen int
+}
+
+// Describe returns statistics for each column of o in a single pass,
+// keeping only a few kilobytes per column in memory.
+// Blank rows are skipped.
+func Describe(o Options) ([]ColumnStats, error) {
+	o.LazyStrings = true
+	r := NewReader(o)
+	var (
+		stats []ColumnStats
+		hlls  []*hyperLogLog
+		sums  []float64
+		seed  = maphash.MakeSeed()
+	)
+	for r.Next() {
+		row := r.Row()
+		if stats == nil {
+			stats = newColumnStats(row.Header())
+			for range stats {
+				hlls = append(hlls, new(hyperLogLog))
+			}
+			sums = make([]float64, len(stats))
+		}
+		if row.Blank() {
+			continue
+		}
+		for i := range stats {
+			b := row.bytesAt(i)
+			if len(b) == 0 {
+				continue
+			}
+			st := &stats[i]
+			n := utf8.RuneCount(b)
+			if st.Count == 0 {
+				st.MinLen, st.MaxLen = n, n
+			}
+			st.MinLen, st.MaxLen = min(st.MinLen, n), max(st.MaxLen, n)
+			hlls[i].add(maphash.Bytes(seed, b))
+			if st.Numeric {
+				if f, err := strconv.ParseFloat(string(b), 64); err != nil {
+					st.Numeric = false
+				} else if st.Count == 0 {
+					st.Min, st.Max, sums[i] = f, f, f
+				} else {
+					st.Min, st.Max = min(st.Min, f), max(st.Max, f)
+					sums[i] += f
+				}
+			}
+			st.Count++
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		stats = newColumnStats(r.row.header)
+	}
+	for i := range stats {
+		st := &stats[i]
+		if hlls != nil {
+			st.Distinct = min(hlls[i].estimate(), st.Count)
+		}
+		if st.Count == 0 {
+			st.Numeric = false
+		}
+		if st.Numeric {
+			st.Mean = sums[i] / float64(st.Count)
+		} else {
+			st.Min, st.Max = 0, 0
+		}
+	}
+	return stats, nil
+}
+
+func newColumnStats(header []string) []ColumnStats {
+	stats := make([]ColumnStats, len(header))
+	for i, name := range header {
+		stats[i] = ColumnStats{Name: name, Numeric: true}
+	}
+	return stats
+}
+
+// hllPrecision is the number of hash bits used to pick
+// a register of a hyperLogLog.
+const hllPrecision = 12
+
+// hyperLogLog estimates the number of distinct hashes added to it.
+type hyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+func (h *hyperLogLog) add(hash uint64) {
+	i := hash >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	h.registers[i] = max(h.registers[i], rank)
+}
+
+func (h *hyperLogLog) estimate() int {
+	const m = 1 << hllPrecision
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		// Use linear counting for small cardinalities.
+		e = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(e))
+}
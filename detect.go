@@ -0,0 +1,174 @@
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// detectSampleSize is the number of bytes read by DetectOptions.
+const detectSampleSize = 64 << 10
+
+// detectSampleRows is the most records examined by DetectOptions.
+const detectSampleRows = 100
+
+// detectCommas are the delimiters tried by DetectOptions, in order of preference.
+var detectCommas = []rune{',', ';', '\t', '|'}
+
+// ErrFormatNotDetected is returned by [DetectOptions]
+// when no delimiter can parse the start of its input.
+var ErrFormatNotDetected = errors.New("csv: could not detect format")
+
+// DetectOptions reads the start of r and guesses how it is formatted.
+// It returns Options with Reader set to replay what was read
+// followed by the rest of r, so the Options are ready to use.
+//
+// The delimiter is chosen from comma, semicolon, tab, and pipe
+// as the one giving the most consistent number of fields per row,
+// unless the input begins with a line like "sep=;", as read by Excel.
+// LazyQuotes is set if the sample only parses with it.
+// If the first row does not look like a header, because its values
+// have the same types or lengths as those in the rows below it,
+// FieldNames is set to "column_1", "column_2", and so on.
+func DetectOptions(r io.Reader) (Options, error) {
+	buf := make([]byte, detectSampleSize)
+	n, err := io.ReadFull(r, buf)
+	complete := err == io.EOF || err == io.ErrUnexpectedEOF
+	if err != nil && !complete {
+		return Options{}, err
+	}
+	buf = buf[:n]
+	o := Options{Reader: io.MultiReader(bytes.NewReader(buf), r)}
+	sample := buf
+	if !complete {
+		// Leave out the last line, which may be cut off.
+		if i := bytes.LastIndexByte(sample, '\n'); i >= 0 {
+			sample = sample[:i+1]
+		}
+	}
+
+	commas := detectCommas
+	line, _, _ := bytes.Cut(bytes.TrimPrefix(sample, utf8BOM), []byte("\n"))
+	if sep, ok := parseSepHint(line); ok {
+		commas = []rune{sep}
+	}
+	var (
+		best      [][]string
+		bestScore float64
+	)
+	for _, lazy := range []bool{false, true} {
+		for _, comma := range commas {
+			records, ok := sampleRecords(sample, comma, lazy)
+			if !ok {
+				continue
+			}
+			if score := consistency(records); score > bestScore {
+				best, bestScore = records, score
+				o.Comma, o.LazyQuotes = comma, lazy
+			}
+		}
+	}
+	if best == nil {
+		if len(bytes.TrimSpace(sample)) == 0 {
+			return o, nil
+		}
+		return Options{}, ErrFormatNotDetected
+	}
+	if !hasHeader(best) {
+		for i := range best[0] {
+			o.FieldNames = append(o.FieldNames, "column_"+strconv.Itoa(i+1))
+		}
+	}
+	return o, nil
+}
+
+// sampleRecords parses up to detectSampleRows records of sample,
+// reporting false if it cannot be parsed.
+func sampleRecords(sample []byte, comma rune, lazy bool) ([][]string, bool) {
+	o := Options{Comma: comma, LazyQuotes: lazy}
+	src, err := o.decode(bytes.NewReader(sample))
+	if err != nil {
+		return nil, false
+	}
+	cr := o.newParser(src)
+	cr.fieldsPerRecord = -1
+	var records [][]string
+	for len(records) < detectSampleRows {
+		record, err := cr.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false
+		}
+		records = append(records, append([]string(nil), record...))
+	}
+	return records, len(records) > 0
+}
+
+// consistency scores records by how many have the most common
+// number of fields, favoring more than one field.
+func consistency(records [][]string) float64 {
+	counts := make(map[int]int)
+	mode := 0
+	for _, record := range records {
+		n := len(record)
+		counts[n]++
+		if counts[n] > counts[mode] || counts[n] == counts[mode] && n > mode {
+			mode = n
+		}
+	}
+	if mode < 2 {
+		return 0.5 * float64(counts[mode]) / float64(len(records))
+	}
+	return float64(counts[mode]) / float64(len(records))
+}
+
+// hasHeader guesses whether the first of records is a header
+// by comparing each of its values with the values below it.
+// A column votes for a header if its values below are all numbers
+// and the first is not, or if they all have the same length
+// and the first does not.
+func hasHeader(records [][]string) bool {
+	if len(records) < 2 {
+		return true
+	}
+	header, rows := records[0], records[1:]
+	votes := 0
+	for i, name := range header {
+		numeric, length := true, -1
+		for _, row := range rows {
+			if i >= len(row) {
+				continue
+			}
+			v := row[i]
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				numeric = false
+			}
+			switch length {
+			case -1:
+				length = len(v)
+			case len(v):
+			default:
+				length = -2
+			}
+		}
+		_, err := strconv.ParseFloat(name, 64)
+		switch {
+		case numeric:
+			if err != nil {
+				votes++
+			} else {
+				votes--
+			}
+		case length >= 0:
+			if len(name) != length {
+				votes++
+			} else {
+				votes--
+			}
+		}
+	}
+	return votes >= 0
+}
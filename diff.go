@@ -0,0 +1,167 @@
+package csv
+
+import (
+	"iter"
+	"slices"
+)
+
+// ChangeKind is the kind of a [Change] found by [Diff].
+type ChangeKind int8
+
+const (
+	// RowAdded is a row found only in the new source.
+	RowAdded ChangeKind = iota + 1
+	// RowRemoved is a row found only in the old source.
+	RowRemoved
+	// RowChanged is a row found in both sources with different values.
+	RowChanged
+)
+
+// A Change is a difference between two sources found by [Diff].
+type Change struct {
+	Kind ChangeKind
+	// Old is the row in the old source. It is nil for RowAdded.
+	Old *Row
+	// New is the row in the new source. It is nil for RowRemoved.
+	New *Row
+	// Columns are the fieldnames whose values differ, for RowChanged.
+	Columns []string
+}
+
+// Diff compares the rows of a, the old source, with those of b,
+// the new source, and yields a Change for each row that differs.
+//
+// If key is not nil, rows are matched by their values of the key columns,
+// regardless of order. Rows of a and b with the same key are matched
+// in the order they appear. The rows of a are kept in memory,
+// changed and added rows are yielded in the order of b,
+// and then removed rows are yielded in the order of a.
+//
+// If key is nil, rows are matched by position: the first row of a
+// with the first row of b, and so on. Only one row of each is
+// kept in memory at a time.
+//
+// Values are compared for every fieldname of either source.
+// A fieldname missing from one source has the empty string as its value.
+// Blank rows are skipped. The rows and Columns of a Change
+// are only valid during the current iteration;
+// use [Row.Clone] and [slices.Clone] to retain them.
+func Diff(a, b Options, key []string) iter.Seq2[Change, error] {
+	if key == nil {
+		return diffPositional(a, b)
+	}
+	return func(yield func(Change, error) bool) {
+		var old []*Row
+		// index holds the rows of old not yet matched, by key.
+		index := make(map[string][]int)
+		var k []byte
+		for row, err := range a.Rows() {
+			if err != nil {
+				yield(Change{}, err)
+				return
+			}
+			if row.Blank() {
+				continue
+			}
+			k = appendKey(k[:0], row, key)
+			index[string(k)] = append(index[string(k)], len(old))
+			old = append(old, row.Clone())
+		}
+		matched := make([]bool, len(old))
+		var cols []string
+		for row, err := range b.Rows() {
+			if err != nil {
+				yield(Change{}, err)
+				return
+			}
+			if row.Blank() {
+				continue
+			}
+			k = appendKey(k[:0], row, key)
+			queue := index[string(k)]
+			if len(queue) == 0 {
+				if !yield(Change{Kind: RowAdded, New: row}, nil) {
+					return
+				}
+				continue
+			}
+			i := queue[0]
+			index[string(k)] = queue[1:]
+			matched[i] = true
+			if cols = diffColumns(cols[:0], old[i], row); len(cols) > 0 {
+				if !yield(Change{Kind: RowChanged, Old: old[i], New: row, Columns: cols}, nil) {
+					return
+				}
+			}
+		}
+		for i, row := range old {
+			if !matched[i] {
+				if !yield(Change{Kind: RowRemoved, Old: row}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// diffPositional is Diff with rows matched by position.
+func diffPositional(a, b Options) iter.Seq2[Change, error] {
+	return func(yield func(Change, error) bool) {
+		ra, rb := NewReader(a), NewReader(b)
+		var cols []string
+		for {
+			oa, ob := nextNonBlank(ra), nextNonBlank(rb)
+			if err := ra.Err(); err != nil {
+				yield(Change{}, err)
+				return
+			}
+			if err := rb.Err(); err != nil {
+				yield(Change{}, err)
+				return
+			}
+			var c Change
+			switch {
+			case oa && ob:
+				if cols = diffColumns(cols[:0], ra.Row(), rb.Row()); len(cols) == 0 {
+					continue
+				}
+				c = Change{Kind: RowChanged, Old: ra.Row(), New: rb.Row(), Columns: cols}
+			case oa:
+				c = Change{Kind: RowRemoved, Old: ra.Row()}
+			case ob:
+				c = Change{Kind: RowAdded, New: rb.Row()}
+			default:
+				return
+			}
+			if !yield(c, nil) {
+				return
+			}
+		}
+	}
+}
+
+// nextNonBlank advances r to its next row that is not blank.
+func nextNonBlank(r *Reader) bool {
+	for r.Next() {
+		if !r.Row().Blank() {
+			return true
+		}
+	}
+	return false
+}
+
+// diffColumns appends to dst the fieldnames of old or new
+// whose values differ between them.
+func diffColumns(dst []string, old, new *Row) []string {
+	for _, name := range old.Header() {
+		if old.Field(name) != new.Field(name) && !slices.Contains(dst, name) {
+			dst = append(dst, name)
+		}
+	}
+	for _, name := range new.Header() {
+		if !old.Has(name) && new.Field(name) != "" && !slices.Contains(dst, name) {
+			dst = append(dst, name)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,280 @@
+package csv
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Marshaler is a type that [Writer.WriteStruct] formats
+// with its MarshalCSV method, in preference to
+// [encoding.TextMarshaler] or the usual formatting.
+type Marshaler interface {
+	// MarshalCSV returns the receiver formatted as a value.
+	MarshalCSV() (string, error)
+}
+
+// An encoder formats v as a value.
+type encoder func(v reflect.Value) (string, error)
+
+var (
+	marshalerType     = reflect.TypeFor[Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	valuerType        = reflect.TypeFor[driver.Valuer]()
+)
+
+// encoderFor returns an encoder for values of type t
+// configured by opts, which formats values so that
+// the decoder returned by decoderFor can scan them.
+// Values passed to the encoder must be addressable.
+func encoderFor(t reflect.Type, opts tagOptions) encoder {
+	if _, ok := opts["omitempty"]; ok {
+		enc := encoderFor(t, opts.without("omitempty"))
+		if enc == nil {
+			return nil
+		}
+		return func(v reflect.Value) (string, error) {
+			if v.IsZero() {
+				return "", nil
+			}
+			return enc(v)
+		}
+	}
+	if _, ok := opts["json"]; ok {
+		return func(v reflect.Value) (string, error) {
+			if v.IsZero() {
+				return "", nil
+			}
+			b, err := json.Marshal(v.Interface())
+			return string(b), err
+		}
+	}
+	if format, ok := opts["format"]; ok && t.Kind() != reflect.Pointer {
+		return func(v reflect.Value) (string, error) {
+			return fmt.Sprintf(format, v.Interface()), nil
+		}
+	}
+	if reflect.PointerTo(t).Implements(marshalerType) {
+		return func(v reflect.Value) (string, error) {
+			return v.Addr().Interface().(Marshaler).MarshalCSV()
+		}
+	}
+	if sep := opts.separator("sep"); sep != "" && t.Kind() == reflect.Slice {
+		elem := encoderFor(t.Elem(), opts.without("sep"))
+		if elem == nil {
+			return nil
+		}
+		return func(v reflect.Value) (string, error) {
+			var sb strings.Builder
+			for i := range v.Len() {
+				s, err := elem(v.Index(i))
+				if err != nil {
+					return "", err
+				}
+				if i > 0 {
+					sb.WriteString(sep)
+				}
+				sb.WriteString(s)
+			}
+			return sb.String(), nil
+		}
+	}
+	if sep := opts.separator("kv"); sep != "" && t.Kind() == reflect.Map {
+		kvsep := cmp.Or(opts.separator("kvsep"), "=")
+		elemOpts := opts.without("kv")
+		key, elem := encoderFor(t.Key(), elemOpts), encoderFor(t.Elem(), elemOpts)
+		if key == nil || elem == nil {
+			return nil
+		}
+		return func(v reflect.Value) (string, error) {
+			items := make([]string, 0, v.Len())
+			k, e := reflect.New(t.Key()).Elem(), reflect.New(t.Elem()).Elem()
+			for iter := v.MapRange(); iter.Next(); {
+				k.Set(iter.Key())
+				e.Set(iter.Value())
+				ks, err := key(k)
+				if err != nil {
+					return "", err
+				}
+				es, err := elem(e)
+				if err != nil {
+					return "", err
+				}
+				items = append(items, ks+kvsep+es)
+			}
+			// Sort for stable output.
+			slices.Sort(items)
+			return strings.Join(items, sep), nil
+		}
+	}
+	if layouts := opts.list("layout"); t == timeType && layouts != nil {
+		var tz *time.Location
+		if name, ok := opts["tz"]; ok {
+			tz, _ = time.LoadLocation(name)
+		}
+		return func(v reflect.Value) (string, error) {
+			tm := v.Interface().(time.Time)
+			if tm.IsZero() {
+				return "", nil
+			}
+			if tz != nil {
+				tm = tm.In(tz)
+			}
+			return tm.Format(layouts[0]), nil
+		}
+	}
+	if t == bigRatType {
+		return func(v reflect.Value) (string, error) {
+			r := v.Addr().Interface().(*big.Rat)
+			if n, exact := r.FloatPrec(); exact {
+				return r.FloatString(n), nil
+			}
+			return r.String(), nil
+		}
+	}
+	if reflect.PointerTo(t).Implements(textMarshalerType) {
+		return func(v reflect.Value) (string, error) {
+			b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+			return string(b), err
+		}
+	}
+	if reflect.PointerTo(t).Implements(decimalUnmarshalerType) {
+		return func(v reflect.Value) (string, error) {
+			if s, ok := v.Addr().Interface().(fmt.Stringer); ok {
+				return s.String(), nil
+			}
+			return fmt.Sprint(v.Interface()), nil
+		}
+	}
+	if reflect.PointerTo(t).Implements(valuerType) {
+		// Such as sql.NullString, formatted by the type of its value.
+		return func(v reflect.Value) (string, error) {
+			val, err := v.Addr().Interface().(driver.Valuer).Value()
+			if err != nil || val == nil {
+				return "", err
+			}
+			dv := reflect.New(reflect.TypeOf(val)).Elem()
+			dv.Set(reflect.ValueOf(val))
+			if enc := encoderFor(dv.Type(), opts); enc != nil {
+				return enc(dv)
+			}
+			return fmt.Sprint(val), nil
+		}
+	}
+	if t == durationType {
+		syntax := opts["duration"]
+		return func(v reflect.Value) (string, error) {
+			d := time.Duration(v.Int())
+			if syntax == "clock" {
+				return formatClock(d), nil
+			}
+			return d.String(), nil
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return func(v reflect.Value) (string, error) {
+			return v.String(), nil
+		}
+	case reflect.Bool:
+		trues, falses := opts.list("true"), opts.list("false")
+		return func(v reflect.Value) (string, error) {
+			if v.Bool() && trues != nil {
+				return trues[0], nil
+			}
+			if !v.Bool() && falses != nil {
+				return falses[0], nil
+			}
+			return strconv.FormatBool(v.Bool()), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) (string, error) {
+			return strconv.FormatInt(v.Int(), 10), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(v reflect.Value) (string, error) {
+			return strconv.FormatUint(v.Uint(), 10), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value) (string, error) {
+			return strconv.FormatFloat(v.Float(), 'f', -1, t.Bits()), nil
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return nil
+		}
+		enc := opts["encoding"]
+		return func(v reflect.Value) (string, error) {
+			return encodeBytes(v.Bytes(), enc)
+		}
+	case reflect.Pointer:
+		elem := encoderFor(t.Elem(), opts)
+		if elem == nil {
+			return nil
+		}
+		return func(v reflect.Value) (string, error) {
+			if v.IsNil() {
+				return "", nil
+			}
+			return elem(v.Elem())
+		}
+	}
+	return nil
+}
+
+// isNull reports whether v is a nil pointer or a [driver.Valuer]
+// whose value is nil, such as a sql.NullString that is not Valid.
+// v must be addressable.
+func isNull(v reflect.Value) bool {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	if vr, ok := v.Addr().Interface().(driver.Valuer); ok {
+		val, err := vr.Value()
+		return err == nil && val == nil
+	}
+	return false
+}
+
+// encodeBytes encodes b in the binary-to-text encoding
+// chosen as for decodeBytes.
+func encodeBytes(b []byte, encoding string) (string, error) {
+	switch encoding {
+	case "", "base64":
+		return base64.StdEncoding.EncodeToString(b), nil
+	case "base64url":
+		return base64.URLEncoding.EncodeToString(b), nil
+	case "hex":
+		return hex.EncodeToString(b), nil
+	}
+	return "", fmt.Errorf("csv: unknown encoding %q", encoding)
+}
+
+// formatClock formats d as a clock, like "1:30:00" or "-0:05:30.25",
+// which parseClock can parse.
+func formatClock(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	h, m := d/time.Hour, d%time.Hour/time.Minute
+	s := d % time.Minute
+	secs := strconv.FormatFloat(s.Seconds(), 'f', -1, 64)
+	if s < 10*time.Second {
+		secs = "0" + secs
+	}
+	return fmt.Sprintf("%s%d:%02d:%s", sign, h, m, secs)
+}
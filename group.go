@@ -0,0 +1,158 @@
+package csv
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type aggregateOp int8
+
+const (
+	countOp aggregateOp = iota
+	sumOp
+	minOp
+	maxOp
+)
+
+// An Aggregation computes a column of the result of [GroupBy]
+// from the rows of each group.
+// Create one with [CountRows], [Sum], [Min], or [Max].
+type Aggregation struct {
+	name, column string
+	op           aggregateOp
+}
+
+// CountRows returns an Aggregation named name
+// that counts the rows in each group.
+func CountRows(name string) Aggregation {
+	return Aggregation{name: name, op: countOp}
+}
+
+// Sum returns an Aggregation named name
+// that adds up the numeric values of column.
+// Empty values are skipped.
+func Sum(name, column string) Aggregation {
+	return Aggregation{name: name, column: column, op: sumOp}
+}
+
+// Min returns an Aggregation named name
+// that finds the least numeric value of column.
+// Empty values are skipped. If all values are empty, the result is empty.
+func Min(name, column string) Aggregation {
+	return Aggregation{name: name, column: column, op: minOp}
+}
+
+// Max returns an Aggregation named name
+// that finds the greatest numeric value of column.
+// Empty values are skipped. If all values are empty, the result is empty.
+func Max(name, column string) Aggregation {
+	return Aggregation{name: name, column: column, op: maxOp}
+}
+
+// aggregate is the running state of an Aggregation for one group.
+type aggregate struct {
+	n   int
+	v   float64
+	set bool
+}
+
+func (a *aggregate) add(agg Aggregation, row *Row) error {
+	if agg.op == countOp {
+		a.n++
+		return nil
+	}
+	s := row.Field(agg.column)
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("csv: line %d: column %q: %w", row.Line(), agg.column, err)
+	}
+	switch {
+	case !a.set:
+		a.v = v
+	case agg.op == sumOp:
+		a.v += v
+	case agg.op == minOp:
+		a.v = min(a.v, v)
+	case agg.op == maxOp:
+		a.v = max(a.v, v)
+	}
+	a.set = true
+	return nil
+}
+
+func (a *aggregate) value(agg Aggregation) string {
+	switch {
+	case agg.op == countOp:
+		return strconv.Itoa(a.n)
+	case agg.op == sumOp && !a.set:
+		return "0"
+	case !a.set:
+		return ""
+	}
+	return strconv.FormatFloat(a.v, 'f', -1, 64)
+}
+
+// GroupBy groups the rows of o by the values of keyCols
+// and computes aggs for each group in a single pass.
+// It returns one row per group, in the order each group first appears.
+// The fieldnames of the rows are keyCols followed by the names of aggs.
+// Only the groups are kept in memory, so GroupBy can summarize
+// sources of any length with few distinct keys.
+// Blank rows are skipped.
+func GroupBy(o Options, keyCols []string, aggs ...Aggregation) ([]*Row, error) {
+	type group struct {
+		key  []string
+		aggs []aggregate
+	}
+	var groups []*group
+	index := make(map[string]*group)
+	var key []byte
+	for row, err := range o.Rows() {
+		if err != nil {
+			return nil, err
+		}
+		if row.Blank() {
+			continue
+		}
+		key = appendKey(key[:0], row, keyCols)
+		g := index[string(key)]
+		if g == nil {
+			g = &group{aggs: make([]aggregate, len(aggs))}
+			for _, col := range keyCols {
+				g.key = append(g.key, row.Field(col))
+			}
+			index[string(key)] = g
+			groups = append(groups, g)
+		}
+		for i, agg := range aggs {
+			if err := g.aggs[i].add(agg, row); err != nil {
+				return nil, err
+			}
+		}
+	}
+	header := append([]string(nil), keyCols...)
+	for _, agg := range aggs {
+		header = append(header, agg.name)
+	}
+	rows := make([]*Row, 0, len(groups))
+	for _, g := range groups {
+		record := g.key
+		for i, agg := range aggs {
+			record = append(record, g.aggs[i].value(agg))
+		}
+		rows = append(rows, NewRow(header, record))
+	}
+	return rows, nil
+}
+
+// appendKey appends a key made of the values of cols in row to dst.
+// Rows have equal keys only if they have equal values.
+func appendKey(dst []byte, row *Row, cols []string) []byte {
+	for _, col := range cols {
+		dst = strconv.AppendQuote(dst, row.Field(col))
+	}
+	return dst
+}
@@ -0,0 +1,176 @@
+package csv
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+var (
+	// ErrHTTPStatus is returned by [FromHTTPResponse]
+	// for a response whose status is not 2xx.
+	ErrHTTPStatus = errors.New("csv: unsuccessful HTTP status")
+	// ErrFileTooLarge is returned by [FromMultipartFile]
+	// for a file with more than maxBytes bytes.
+	ErrFileTooLarge = errors.New("csv: file too large")
+	// ErrTooManyRows is returned by [FromMultipartFile]
+	// for a file with more than maxRows rows.
+	ErrTooManyRows = errors.New("csv: too many rows")
+)
+
+// ServeSeq writes the structs of seq to w with cw, as by [Writer.WriteStruct],
+// as a CSV file to be downloaded with the file name name.
+// It sets the Content-Type and Content-Disposition headers,
+// and the Writer of cw to w. If cw.FormulaPrefix is empty,
+// it is set to "'" to guard against formula injection,
+// and unless cw.FlushRows or cw.FlushInterval is set,
+// rows are flushed to the client every second.
+// ServeSeq stops early, returning the error of the context of r,
+// if the client disconnects.
+// Once rows are written, errors can no longer be sent to the client,
+// so the caller should log them.
+func ServeSeq[T any](w http.ResponseWriter, r *http.Request, name string, cw Writer, seq iter.Seq[T]) error {
+	h := w.Header()
+	h.Set("Content-Type", "text/csv; charset=utf-8")
+	h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	cw.Writer = flushWriter{w, http.NewResponseController(w)}
+	cw.FormulaPrefix = cmp.Or(cw.FormulaPrefix, "'")
+	if cw.FlushRows <= 0 && cw.FlushInterval <= 0 {
+		cw.FlushInterval = time.Second
+	}
+	ctx := r.Context()
+	for v := range seq {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := cw.WriteStruct(&v); err != nil {
+			return err
+		}
+	}
+	return cw.Close()
+}
+
+// flushWriter flushes each write to an http.ResponseWriter to the client.
+type flushWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if err := fw.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return n, err
+	}
+	return n, nil
+}
+
+// FromHTTPResponse returns Options for reading the CSV body of resp.
+// A response whose status is not 2xx is an error wrapping [ErrHTTPStatus].
+// A gzip Content-Encoding not already removed by the [http.Transport]
+// is decompressed. The body is transcoded to UTF-8 from the charset
+// of the Content-Type, or from the charset guessed as by
+// [Options.DetectCharset] if none is declared.
+// The returned Options are then as from [DetectOptions].
+// The caller must close resp.Body.
+func FromHTTPResponse(resp *http.Response) (Options, error) {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Options{}, fmt.Errorf("%w: %q", ErrHTTPStatus, resp.Status)
+	}
+	var body io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") && !resp.Uncompressed {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return Options{}, err
+		}
+		body = gz
+	}
+	return detect(body, resp.Header.Get("Content-Type"))
+}
+
+// detect transcodes body to UTF-8 from the charset of contentType,
+// or from the charset guessed as by [Options.DetectCharset],
+// and returns Options for it from [DetectOptions].
+func detect(body io.Reader, contentType string) (Options, error) {
+	var o Options
+	_, params, _ := mime.ParseMediaType(contentType)
+	if enc, err := htmlindex.Get(params["charset"]); err == nil {
+		o.Charset = enc
+	} else {
+		// No charset, or an unknown one.
+		o.DetectCharset = true
+	}
+	src, err := o.decode(body)
+	if err != nil {
+		return Options{}, err
+	}
+	return DetectOptions(src)
+}
+
+// FromMultipartFile returns Options for reading the CSV file fh,
+// as uploaded in a form and returned by [http.Request.FormFile].
+// A file with more than maxBytes bytes is an error wrapping
+// [ErrFileTooLarge], and one with more than maxRows rows,
+// not counting the header, is an error wrapping [ErrTooManyRows];
+// limits that are not positive are not checked.
+// The file is read into memory and transcoded to UTF-8
+// from the charset of its Content-Type, or from the charset
+// guessed as by [Options.DetectCharset] if none is declared.
+// The returned Options are then as from [DetectOptions],
+// ready to be read or scanned.
+// To limit the size of the whole request, use [http.MaxBytesReader].
+func FromMultipartFile(fh *multipart.FileHeader, maxBytes int64, maxRows int) (Options, error) {
+	if maxBytes > 0 && fh.Size > maxBytes {
+		return Options{}, fmt.Errorf("%w: %q", ErrFileTooLarge, fh.Filename)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return Options{}, err
+	}
+	defer f.Close()
+	var src io.Reader = f
+	if maxBytes > 0 {
+		src = io.LimitReader(f, maxBytes+1)
+	}
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return Options{}, err
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return Options{}, fmt.Errorf("%w: %q", ErrFileTooLarge, fh.Filename)
+	}
+	o, err := detect(bytes.NewReader(data), fh.Header.Get("Content-Type"))
+	if err != nil {
+		return Options{}, err
+	}
+	// Keep the transcoded text, so the rows can be counted and read.
+	text, err := io.ReadAll(o.Reader)
+	if err != nil {
+		return Options{}, err
+	}
+	if maxRows > 0 {
+		o.Reader = bytes.NewReader(text)
+		n, err := Count(o)
+		if err != nil {
+			return Options{}, err
+		}
+		if n > maxRows {
+			return Options{}, fmt.Errorf("%w: %q", ErrTooManyRows, fh.Filename)
+		}
+	}
+	o.Reader = bytes.NewReader(text)
+	return o, nil
+}
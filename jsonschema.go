@@ -0,0 +1,182 @@
+package csv
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) document
+// describing the rows of s as JSON objects, as produced by [Row.MarshalJSON]
+// after converting values to their Types.
+// Columns that are not Nullable are required, and Nullable columns
+// may also be null. Pattern, Enum, and Examples are included if set.
+func (s Schema) JSONSchema() ([]byte, error) {
+	type property struct {
+		Type     any      `json:"type"`
+		Format   string   `json:"format,omitempty"`
+		Pattern  string   `json:"pattern,omitempty"`
+		Enum     []string `json:"enum,omitempty"`
+		Examples []string `json:"examples,omitempty"`
+	}
+	doc := struct {
+		Schema     string              `json:"$schema"`
+		Type       string              `json:"type"`
+		Properties map[string]property `json:"properties"`
+		Required   []string            `json:"required,omitempty"`
+	}{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Type:       "object",
+		Properties: make(map[string]property, len(s.Columns)),
+	}
+	for _, c := range s.Columns {
+		p := property{Enum: c.Enum, Examples: c.Examples}
+		typ := "string"
+		switch c.Type {
+		case TypeInt:
+			typ = "integer"
+		case TypeFloat:
+			typ = "number"
+		case TypeBool:
+			typ = "boolean"
+		case TypeTime:
+			switch c.Layout {
+			case time.RFC3339, time.RFC3339Nano:
+				p.Format = "date-time"
+			case time.DateOnly:
+				p.Format = "date"
+			case time.TimeOnly:
+				p.Format = "time"
+			}
+		}
+		if c.Pattern != "" {
+			p.Pattern = "^(?:" + c.Pattern + ")$"
+		}
+		p.Type = typ
+		if c.Nullable {
+			p.Type = []string{typ, "null"}
+		} else {
+			doc.Required = append(doc.Required, c.Name)
+		}
+		doc.Properties[c.Name] = p
+	}
+	return json.Marshal(doc)
+}
+
+// TableSchema returns a Frictionless Data Table Schema document describing s.
+// Empty strings are declared as missing values, and columns that are not
+// Nullable are marked as required. Time layouts are converted to
+// strptime-style formats where possible, or to "any" otherwise.
+// See https://specs.frictionlessdata.io/table-schema/.
+func (s Schema) TableSchema() ([]byte, error) {
+	type constraints struct {
+		Required bool     `json:"required,omitempty"`
+		Pattern  string   `json:"pattern,omitempty"`
+		Enum     []string `json:"enum,omitempty"`
+	}
+	type field struct {
+		Name        string       `json:"name"`
+		Type        string       `json:"type"`
+		Format      string       `json:"format,omitempty"`
+		Example     string       `json:"example,omitempty"`
+		Constraints *constraints `json:"constraints,omitempty"`
+	}
+	doc := struct {
+		Fields        []field  `json:"fields"`
+		MissingValues []string `json:"missingValues"`
+	}{
+		Fields:        make([]field, 0, len(s.Columns)),
+		MissingValues: []string{""},
+	}
+	for _, c := range s.Columns {
+		f := field{Name: c.Name, Type: "string"}
+		switch c.Type {
+		case TypeInt:
+			f.Type = "integer"
+		case TypeFloat:
+			f.Type = "number"
+		case TypeBool:
+			f.Type = "boolean"
+		case TypeTime:
+			f.Type, f.Format = tableSchemaTime(c.Layout)
+		}
+		if len(c.Examples) > 0 {
+			f.Example = c.Examples[0]
+		}
+		if !c.Nullable || c.Pattern != "" || c.Enum != nil {
+			f.Constraints = &constraints{
+				Required: !c.Nullable,
+				Pattern:  c.Pattern,
+				Enum:     c.Enum,
+			}
+		}
+		doc.Fields = append(doc.Fields, f)
+	}
+	return json.Marshal(doc)
+}
+
+// tableSchemaTime returns the Table Schema type and format for layout.
+func tableSchemaTime(layout string) (typ, format string) {
+	switch layout {
+	case time.RFC3339, time.RFC3339Nano:
+		return "datetime", ""
+	case time.DateOnly:
+		return "date", ""
+	case time.TimeOnly:
+		return "time", ""
+	}
+	format, ok := strptimeFormat(layout)
+	if !ok {
+		format = "any"
+	}
+	switch {
+	case !strings.Contains(format, "%H"):
+		return "date", format
+	case !strings.Contains(format, "%d"):
+		return "time", format
+	}
+	return "datetime", format
+}
+
+// strptimeElements maps elements of Go time layouts
+// to strptime directives, longest first.
+var strptimeElements = []struct{ layout, directive string }{
+	{"January", "%B"},
+	{"Monday", "%A"},
+	{"2006", "%Y"},
+	{"Jan", "%b"},
+	{"Mon", "%a"},
+	{"MST", "%Z"},
+	{"-0700", "%z"},
+	{"01", "%m"},
+	{"02", "%d"},
+	{"15", "%H"},
+	{"03", "%I"},
+	{"04", "%M"},
+	{"05", "%S"},
+	{"06", "%y"},
+	{"PM", "%p"},
+}
+
+// strptimeFormat converts a Go time layout to a strptime format,
+// reporting false if the layout has elements with no equivalent.
+func strptimeFormat(layout string) (string, bool) {
+	var sb strings.Builder
+next:
+	for layout != "" {
+		for _, e := range strptimeElements {
+			if strings.HasPrefix(layout, e.layout) {
+				sb.WriteString(e.directive)
+				layout = layout[len(e.layout):]
+				continue next
+			}
+		}
+		c := layout[0]
+		if c >= '0' && c <= '9' || c == '%' {
+			return "", false
+		}
+		sb.WriteByte(c)
+		layout = layout[1:]
+	}
+	return sb.String(), true
+}
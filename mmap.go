@@ -0,0 +1,67 @@
+package csv
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// MappedFile is a file mapped into memory for reading.
+// Rows read from a MappedFile are parsed directly from memory,
+// which avoids read system calls and the copy into a read buffer.
+// Fields are still copied out of the mapping as they are parsed.
+// On systems without memory mapping, the file is read into memory instead.
+type MappedFile struct {
+	data  []byte
+	unmap func() error
+}
+
+// OpenMmap maps the file at path into memory.
+// The caller must call [MappedFile.Close] when done with it.
+func OpenMmap(path string) (*MappedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		return &MappedFile{}, nil
+	}
+	data, unmap, err := mmap(f, fi.Size())
+	if err != nil {
+		return nil, &os.PathError{Op: "mmap", Path: path, Err: err}
+	}
+	return &MappedFile{data, unmap}, nil
+}
+
+// Reader returns a reader over the contents of m
+// for use as [Options.Reader].
+func (m *MappedFile) Reader() io.Reader {
+	return &memReader{bytes.NewReader(m.data), m.data}
+}
+
+// ReadAt implements [io.ReaderAt], so m may be used with [Options.ParallelRows].
+func (m *MappedFile) ReadAt(p []byte, off int64) (int, error) {
+	return bytes.NewReader(m.data).ReadAt(p, off)
+}
+
+// Size returns the size of the mapped file.
+func (m *MappedFile) Size() int64 {
+	return int64(len(m.data))
+}
+
+// Close unmaps the file.
+// Rows read from m must not be used after Close.
+func (m *MappedFile) Close() error {
+	m.data = nil
+	if m.unmap == nil {
+		return nil
+	}
+	unmap := m.unmap
+	m.unmap = nil
+	return unmap()
+}
@@ -0,0 +1,99 @@
+package csv
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// UnicodeMode controls the Unicode normalization of fieldnames and values,
+// so that text matches however its producer composed accented characters.
+type UnicodeMode int8
+
+const (
+	// UnicodeAsIs leaves text as it is read.
+	UnicodeAsIs UnicodeMode = iota
+	// UnicodeNFC converts text to Normalization Form C,
+	// in which "e" followed by a combining acute accent becomes "é".
+	UnicodeNFC
+	// UnicodeNFKC converts text to Normalization Form KC, which also
+	// replaces compatibility characters, such as ligatures, full-width
+	// letters, and superscripts, with their plain equivalents.
+	UnicodeNFKC
+	// UnicodeCaseFold converts text to Normalization Form KC and folds case,
+	// so that text differing only in these ways is equal.
+	// It does not fold confusable characters that merely look alike,
+	// such as Latin "a" and Cyrillic "а".
+	// It is meant for matching, not for display.
+	UnicodeCaseFold
+)
+
+// normalizer applies a UnicodeMode.
+// It is not safe for concurrent use.
+type normalizer struct {
+	mode  UnicodeMode
+	fold  cases.Caser
+	ready bool
+}
+
+// append appends b to dst in the form of n.mode.
+func (n *normalizer) append(dst, b []byte) []byte {
+	switch n.mode {
+	case UnicodeNFC:
+		return norm.NFC.Append(dst, b...)
+	case UnicodeNFKC:
+		return norm.NFKC.Append(dst, b...)
+	case UnicodeCaseFold:
+		if !n.ready {
+			n.fold, n.ready = cases.Fold(), true
+		}
+		return norm.NFKC.Append(dst, n.fold.Bytes(b)...)
+	}
+	return append(dst, b...)
+}
+
+// isNormal reports whether b is already in the form of n.mode.
+func (n *normalizer) isNormal(b []byte) bool {
+	switch n.mode {
+	case UnicodeNFC:
+		return norm.NFC.IsNormal(b)
+	case UnicodeNFKC:
+		return norm.NFKC.IsNormal(b)
+	case UnicodeCaseFold:
+		for _, c := range b {
+			if c >= 0x80 || 'A' <= c && c <= 'Z' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// string returns s in the form of n.mode.
+func (n *normalizer) string(s string) string {
+	if n.isNormal([]byte(s)) {
+		return s
+	}
+	return string(n.append(nil, []byte(s)))
+}
+
+// normalizeFields converts the fields in r.recordBuffer
+// to the form of r.norm.mode.
+func (r *reader) normalizeFields() {
+	start := 0
+	for i, end := range r.fieldIndexes {
+		if r.norm.isNormal(r.recordBuffer[start:end]) {
+			start = end
+			continue
+		}
+		// Rebuild the rest of the record in normBuffer.
+		buf := append(r.normBuffer[:0], r.recordBuffer[:start]...)
+		for j := i; j < len(r.fieldIndexes); j++ {
+			end := r.fieldIndexes[j]
+			buf = r.norm.append(buf, r.recordBuffer[start:end])
+			start = end
+			r.fieldIndexes[j] = len(buf)
+		}
+		r.recordBuffer, r.normBuffer = buf, r.recordBuffer
+		return
+	}
+}
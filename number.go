@@ -0,0 +1,205 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NumberFormat describes how the numbers scanned by [Row.Scan]
+// into integer and floating-point fields are written.
+// The zero NumberFormat expects numbers as written by Go,
+// such as "1234.5".
+type NumberFormat struct {
+	// Decimal is the decimal separator.
+	// It is set to period ('.') by default.
+	Decimal rune
+	// Thousands, if not 0, is a digit grouping separator to ignore.
+	// If it is a space, no-break spaces are also ignored.
+	Thousands rune
+	// If Currency is true, currency symbols, such as "$" and "€",
+	// are ignored, along with any space around them.
+	Currency bool
+	// Percent controls numbers ending in a percent sign.
+	Percent PercentMode
+}
+
+// PercentMode controls the scanning of numbers ending in a percent sign.
+type PercentMode int8
+
+const (
+	// PercentReject does not allow a percent sign.
+	PercentReject PercentMode = iota
+	// PercentStrip ignores a percent sign, so "12.5%" is 12.5.
+	PercentStrip
+	// PercentFraction divides a number with a percent sign by 100,
+	// so "12.5%" is 0.125. It is an error to scan such a number
+	// into an integer field.
+	PercentFraction
+)
+
+// Common number formats.
+var (
+	// NumberFormatUS is the format of "1,234.5".
+	NumberFormatUS = NumberFormat{Decimal: '.', Thousands: ','}
+	// NumberFormatEU is the format of "1.234,5".
+	NumberFormatEU = NumberFormat{Decimal: ',', Thousands: '.'}
+	// NumberFormatSI is the format of "1 234,5".
+	NumberFormatSI = NumberFormat{Decimal: ',', Thousands: ' '}
+	// NumberFormatCH is the format of "1'234.5".
+	NumberFormatCH = NumberFormat{Decimal: '.', Thousands: '\''}
+)
+
+var errPercentInt = errors.New("csv: percentage in integer field")
+
+// A DecimalUnmarshaler is a number type, such as a fixed-point decimal,
+// that [Row.Scan] can scan numbers in any [NumberFormat] into.
+// It is preferred over [encoding.TextUnmarshaler].
+// [Writer.WriteStruct] formats it with its MarshalText or String method.
+type DecimalUnmarshaler interface {
+	// UnmarshalDecimal sets the receiver to the number s,
+	// which is written as by Go, such as "-1234.5",
+	// after the NumberFormat has been applied.
+	UnmarshalDecimal(s string) error
+}
+
+// unmarshalDecimal sets x, which is a *big.Int, *big.Float, *big.Rat,
+// or DecimalUnmarshaler, to the number s.
+// If prec is 0, a *big.Float gets enough precision for every digit of s.
+func unmarshalDecimal(x any, s string, prec uint) error {
+	ok := true
+	switch x := x.(type) {
+	case *big.Int:
+		_, ok = x.SetString(s, 10)
+	case *big.Float:
+		if prec == 0 {
+			// Each digit needs less than 4 bits.
+			prec = max(64, 4*uint(len(s)))
+		}
+		_, _, err := x.SetPrec(prec).Parse(s, 10)
+		return err
+	case *big.Rat:
+		_, ok = x.SetString(s)
+	case DecimalUnmarshaler:
+		return x.UnmarshalDecimal(s)
+	}
+	if !ok {
+		return fmt.Errorf("csv: invalid number %q", s)
+	}
+	return nil
+}
+
+// percentFraction divides the decimal number s by 100
+// by moving its decimal point, so that no precision is lost.
+func percentFraction(s string) string {
+	sign, s, _ := cutSign(s)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			// Leave it for the parser to reject.
+			return sign + s
+		}
+		return sign + s[:i] + "e" + strconv.Itoa(exp-2)
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(whole) < 3 {
+		whole = strings.Repeat("0", 3-len(whole)) + whole
+	}
+	return sign + whole[:len(whole)-2] + "." + whole[len(whole)-2:] + frac
+}
+
+// numberFormatOption returns the NumberFormat set by the tag options
+// decimal, thousands, currency, and percent, and whether any was set.
+// Since commas separate tag options, separators may be given by name:
+// comma, period, space, or apostrophe.
+func numberFormatOption(opts tagOptions) (NumberFormat, bool) {
+	var (
+		f   NumberFormat
+		set bool
+	)
+	if v, ok := opts["decimal"]; ok {
+		f.Decimal, set = separatorOption(v), true
+	}
+	if v, ok := opts["thousands"]; ok {
+		f.Thousands, set = separatorOption(v), true
+	}
+	if _, ok := opts["currency"]; ok {
+		f.Currency, set = true, true
+	}
+	if v, ok := opts["percent"]; ok {
+		f.Percent, set = PercentStrip, true
+		if v == "fraction" {
+			f.Percent = PercentFraction
+		}
+	}
+	return f, set
+}
+
+func separatorOption(v string) rune {
+	switch v {
+	case "comma":
+		return ','
+	case "period", "dot":
+		return '.'
+	case "space":
+		return ' '
+	case "apostrophe":
+		return '\''
+	}
+	r, _ := utf8.DecodeRuneInString(v)
+	if r == utf8.RuneError {
+		return 0
+	}
+	return r
+}
+
+// normalize rewrites s, a number in format f,
+// in the form expected by the strconv package.
+// It reports whether s ended in a percent sign.
+func (f NumberFormat) normalize(s string) (string, bool) {
+	percent := false
+	if f.Percent != PercentReject {
+		var t string
+		if t, percent = strings.CutSuffix(strings.TrimSpace(s), "%"); percent {
+			s = strings.TrimSpace(t)
+		}
+	}
+	if (f.Decimal == 0 || f.Decimal == '.') && f.Thousands == 0 && !f.Currency {
+		return s, percent
+	}
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r == f.Thousands,
+			f.Thousands == ' ' && (r == '\u00a0' || r == '\u202f'),
+			f.Currency && unicode.Is(unicode.Sc, r):
+			// Drop digit grouping and currency symbols.
+		case r == f.Decimal:
+			sb.WriteByte('.')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	s = sb.String()
+	if f.Currency {
+		// Drop space between the symbol and the number.
+		s = strings.TrimSpace(s)
+		if sign, rest, ok := cutSign(s); ok {
+			s = sign + strings.TrimSpace(rest)
+		}
+	}
+	return s, percent
+}
+
+// cutSign splits a leading sign from s.
+func cutSign(s string) (sign, rest string, ok bool) {
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		return s[:1], s[1:], true
+	}
+	return "", s, false
+}
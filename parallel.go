@@ -0,0 +1,297 @@
+package csv
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"io"
+	"iter"
+	"runtime"
+	"unicode/utf8"
+)
+
+// parallelChunkSize is the approximate number of bytes
+// parsed by each goroutine in [Options.ParallelRows].
+const parallelChunkSize = 4 << 20
+
+// ParallelRows is like [Options.Rows], but it reads from src,
+// which holds size bytes, instead of o.Reader.
+// The rows after the header are split into chunks at record boundaries,
+// and the chunks are parsed concurrently by up to workers goroutines.
+// Rows are still yielded in order.
+// If workers is less than 1, runtime.GOMAXPROCS(0) is used.
+//
+// Record boundaries are found by counting quotes,
+// which is not reliable if LazyQuotes, Comment, or Terminator is set.
+// In that case, or if src must be transcoded because Charset
+// or DetectCharset is set or src begins with a UTF-16 byte order mark,
+// or if records end with bare carriage returns,
+// or if src begins with a sep= line setting its delimiter,
+// src is parsed sequentially.
+func (o *Options) ParallelRows(src io.ReaderAt, size int64, workers int) iter.Seq2[*Row, error] {
+	head := readHead(src)
+	if o.LazyQuotes || o.Comment != 0 || o.Terminator != "" || o.Charset != nil || o.DetectCharset ||
+		isUTF16BOM(head) {
+		o2 := *o
+		o2.Reader = io.NewSectionReader(src, 0, size)
+		o2.Records = nil
+		return o2.Rows()
+	}
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return func(yield func(*Row, error) bool) {
+		o2 := *o
+		o2.Reader = io.NewSectionReader(src, 0, size)
+		o2.Records = nil
+		r := NewReader(o2)
+		if err := r.init(); err != nil {
+			if err != io.EOF {
+				yield(nil, err)
+			}
+			return
+		}
+		if r.cr.term != nil || r.cr.sepHinted {
+			// Records end with bare carriage returns,
+			// which recordBoundary does not look for,
+			// or the delimiter was set by a sep= line,
+			// which parseChunk does not see.
+			r.started = true
+			for r.Next() {
+				if !yield(r.Row(), nil) {
+					return
+				}
+			}
+			if err := r.Err(); err != nil {
+				yield(nil, err)
+			}
+			return
+		}
+		fieldsPerRecord := r.cr.fieldsPerRecord
+		baseLine := r.cr.numLine
+		start := r.skipped + r.cr.offset
+		if bytes.HasPrefix(head, utf8BOM) {
+			// The byte order mark was dropped before parsing.
+			start += int64(len(utf8BOM))
+		}
+
+		done := make(chan struct{})
+		defer close(done)
+		chunks := make(chan chan *chunk, workers)
+		go o.splitChunks(src, start, size, r.cr.keep, chunks, done)
+
+		row := &r.row
+		var record []string
+		for count := 0; ; {
+			future, ok := <-chunks
+			if !ok {
+				return
+			}
+			c := <-future
+			for i, rec := range c.recs {
+				if o.Limit != 0 && count >= o.Offset+o.Limit {
+					return
+				}
+				count++
+				if rec.blank && o.BlankLines == RejectBlankLines {
+					yield(nil, &csv.ParseError{
+						StartLine: baseLine + rec.line,
+						Line:      baseLine + rec.line,
+						Column:    1,
+						Err:       ErrBlankLine,
+					})
+					return
+				}
+				end, e1 := len(c.buf), len(c.ends)
+				if i+1 < len(c.recs) {
+					end, e1 = c.recs[i+1].start, c.recs[i+1].e0
+				}
+				ends := c.ends[rec.e0:e1]
+				if !rec.blank && fieldsPerRecord == 0 {
+					fieldsPerRecord = rec.fields
+				}
+				if !rec.blank && fieldsPerRecord > 0 && rec.fields != fieldsPerRecord {
+					yield(nil, &csv.ParseError{
+						StartLine: baseLine + rec.line,
+						Line:      baseLine + rec.line,
+						Column:    1,
+						Err:       csv.ErrFieldCount,
+					})
+					return
+				}
+				if count <= o.Offset {
+					continue
+				}
+				row.blank = rec.blank
+				row.number = count
+				row.line = baseLine + rec.line + o.SkipRows
+				row.buf = c.buf[rec.start:end]
+				row.ends = ends
+				row.lazy = o.LazyStrings
+				if !row.lazy {
+					record = record[:0]
+					prev := 0
+					for _, idx := range ends {
+						record = append(record, c.str[rec.start+prev:rec.start+idx])
+						prev = idx
+					}
+					row.setRecord(record)
+				}
+				if !yield(row, nil) {
+					return
+				}
+			}
+			if c.err != nil {
+				var perr *csv.ParseError
+				if errors.As(c.err, &perr) {
+					perr2 := *perr
+					perr2.StartLine += baseLine
+					perr2.Line += baseLine
+					c.err = &perr2
+				}
+				yield(nil, c.err)
+				return
+			}
+			baseLine += c.lines
+		}
+	}
+}
+
+// chunk holds the records parsed from part of a file.
+type chunk struct {
+	// buf holds the unescaped fields of all records, one after another.
+	buf []byte
+	// str is buf as a string, unless LazyStrings is set.
+	str string
+	// ends holds the ends of the fields of each record,
+	// relative to the start of the record in buf.
+	ends []int
+	recs []chunkRecord
+	// lines is the number of lines in the chunk.
+	lines int
+	err   error
+}
+
+type chunkRecord struct {
+	// start is the offset of the record in chunk.buf.
+	start int
+	// e0 is the index of the first field end of the record in chunk.ends.
+	e0   int
+	line int
+	// fields is the number of fields in the record,
+	// including any not kept.
+	fields int
+	blank  bool
+}
+
+// splitChunks reads src from start to size in chunks ending at record boundaries.
+// Each chunk is parsed on its own goroutine, and a channel that will receive
+// the result is sent on chunks in order.
+// splitChunks closes chunks when it is done.
+func (o *Options) splitChunks(src io.ReaderAt, start, size int64, keep []bool, chunks chan<- chan *chunk, done <-chan struct{}) {
+	defer close(chunks)
+	send := func(c func() *chunk) bool {
+		future := make(chan *chunk, 1)
+		select {
+		case chunks <- future:
+		case <-done:
+			return false
+		}
+		go func() { future <- c() }()
+		return true
+	}
+	quote := cmp.Or(o.Quote, '"')
+	var pending []byte
+	for pos := start; pos < size || len(pending) > 0; {
+		n := min(parallelChunkSize, size-pos)
+		buf := make([]byte, len(pending)+int(n))
+		copy(buf, pending)
+		m, err := src.ReadAt(buf[len(pending):], pos)
+		if err != nil && !(err == io.EOF && m == int(n)) {
+			send(func() *chunk { return &chunk{err: err} })
+			return
+		}
+		pos += n
+		data := buf
+		pending = nil
+		if pos < size {
+			if b := recordBoundary(buf, quote); b > 0 {
+				data, pending = buf[:b], buf[b:]
+			} else {
+				// The chunk is inside one very long record.
+				pending = buf
+				continue
+			}
+		}
+		if !send(func() *chunk { return o.parseChunk(data, keep) }) {
+			return
+		}
+	}
+}
+
+// recordBoundary returns the offset just after the last newline in buf
+// that is not inside a field quoted with quote,
+// or -1 if there is no such newline.
+// It assumes buf begins at a record boundary.
+func recordBoundary(buf []byte, quote rune) int {
+	q := utf8.AppendRune(nil, quote)
+	quotes := bytes.Count(buf, q)
+	for end := len(buf); end > 0; {
+		i := bytes.LastIndexByte(buf[:end], '\n')
+		if i < 0 {
+			break
+		}
+		quotes -= bytes.Count(buf[i:end], q)
+		if quotes%2 == 0 {
+			return i + 1
+		}
+		end = i
+	}
+	return -1
+}
+
+// readHead returns the first bytes of src,
+// which hold any byte order mark.
+func readHead(src io.ReaderAt) []byte {
+	head := make([]byte, len(utf8BOM))
+	n, _ := src.ReadAt(head, 0)
+	return head[:n]
+}
+
+// parseChunk parses the records of data,
+// keeping the columns reported by keep.
+func (o *Options) parseChunk(data []byte, keep []bool) *chunk {
+	cr := o.newParser(&memReader{bytes.NewReader(data), data})
+	// The start of the input was already sniffed by ParallelRows.
+	cr.midstream = true
+	cr.fieldsPerRecord = -1
+	cr.keepBlankLines = o.BlankLines != SkipBlankLines
+	cr.lazyStrings = true
+	cr.keep = keep
+	cr.nulls = o.NullValues
+	c := &chunk{lines: bytes.Count(data, []byte{'\n'})}
+	for {
+		_, err := cr.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.err = err
+			break
+		}
+		c.recs = append(c.recs, chunkRecord{
+			start:  len(c.buf),
+			e0:     len(c.ends),
+			line:   cr.recordLine,
+			fields: cr.nfields,
+			blank:  cr.blank,
+		})
+		c.buf = append(c.buf, cr.recordBuffer...)
+		c.ends = append(c.ends, cr.fieldIndexes...)
+	}
+	if !o.LazyStrings {
+		c.str = string(c.buf)
+	}
+	return c
+}
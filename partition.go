@@ -0,0 +1,91 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// PartitionWriter writes each row to a partition chosen by its value
+// of Column, such as one file per country code.
+// The destination of each partition is created when its first row is written,
+// and the header is written at the start of each partition.
+// All partitions stay open until Close,
+// so the number of distinct values should be modest.
+// The exported fields must be set before the first call to WriteRow.
+type PartitionWriter struct {
+	// Column is the fieldname whose value chooses the partition of a row.
+	Column string
+	// Create must be set. It returns the destination of
+	// the partition for value. See [PartitionFiles].
+	Create func(value string) (io.WriteCloser, error)
+	// Comma is the field delimiter, as in [Writer].
+	Comma rune
+	// UseCRLF is whether lines end with \r\n, as in [Writer].
+	UseCRLF bool
+	// FieldNames are the names of the columns to write, as in [Writer].
+	FieldNames []string
+
+	parts map[string]*Writer
+	dsts  []io.WriteCloser
+}
+
+// PartitionFiles returns a function for [PartitionWriter.Create]
+// that creates files named by formatting pattern with the partition value,
+// as with fmt.Sprintf(pattern, value). For example, "country-%s.csv"
+// creates country-US.csv, country-FR.csv, and so on.
+// Values that are empty or contain a path separator are rejected.
+func PartitionFiles(pattern string) func(value string) (io.WriteCloser, error) {
+	return func(value string) (io.WriteCloser, error) {
+		if value == "" || value == "." || value == ".." ||
+			strings.ContainsAny(value, `/\`) {
+			return nil, fmt.Errorf("csv: invalid partition value %q", value)
+		}
+		return os.Create(fmt.Sprintf(pattern, value))
+	}
+}
+
+// WriteRow writes the fields of row named by w.FieldNames
+// to the partition for its value of w.Column.
+func (w *PartitionWriter) WriteRow(row *Row) error {
+	if w.FieldNames == nil {
+		w.FieldNames = slices.Clone(row.Header())
+	}
+	value := row.Field(w.Column)
+	part := w.parts[value]
+	if part == nil {
+		dst, err := w.Create(value)
+		if err != nil {
+			return err
+		}
+		if w.parts == nil {
+			w.parts = make(map[string]*Writer)
+		}
+		part = &Writer{
+			Writer:     dst,
+			Comma:      w.Comma,
+			UseCRLF:    w.UseCRLF,
+			FieldNames: w.FieldNames,
+		}
+		w.parts[value] = part
+		w.dsts = append(w.dsts, dst)
+	}
+	return part.WriteRow(row)
+}
+
+// Close flushes and closes the destination of every partition.
+// It returns the errors encountered, if any, joined together.
+func (w *PartitionWriter) Close() error {
+	var errs []error
+	for _, part := range w.parts {
+		errs = append(errs, part.Close())
+	}
+	for _, dst := range w.dsts {
+		errs = append(errs, dst.Close())
+	}
+	w.parts, w.dsts = nil, nil
+	return errors.Join(errs...)
+}
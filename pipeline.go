@@ -0,0 +1,127 @@
+package csv
+
+import (
+	"iter"
+	"slices"
+)
+
+// A Transform changes a row on its way through a [Pipeline].
+// It may return row itself, possibly modified, or a new row from [NewRow].
+// It returns nil to drop the row.
+type Transform func(row *Row) (*Row, error)
+
+// Where returns a Transform that drops the rows for which keep returns false.
+func Where(keep func(*Row) bool) Transform {
+	return func(row *Row) (*Row, error) {
+		if !keep(row) {
+			return nil, nil
+		}
+		return row, nil
+	}
+}
+
+// Rename returns a Transform that renames the fields of each row
+// according to names, as with [Options.Rename].
+// The Transform keeps state between rows,
+// so it must not be shared between concurrent pipelines.
+func Rename(names map[string]string) Transform {
+	var header []string
+	var out Row
+	return func(row *Row) (*Row, error) {
+		if out.idx == nil || !slices.Equal(row.header, header) {
+			header = slices.Clone(row.header)
+			out.index(renameFields(header, names), KeepLastDuplicate)
+		}
+		renamed := out
+		out = *row
+		out.header, out.idx, out.dups = renamed.header, renamed.idx, renamed.dups
+		out.scanType, out.scanBindings = renamed.scanType, renamed.scanBindings
+		return &out, nil
+	}
+}
+
+// Derive returns a Transform that adds a column called name to each row.
+// The value of the column is computed by calling value with the row,
+// and the new column is available to [Row.Field], [Row.Scan],
+// and a [Writer] like any other.
+// Short rows are padded as with [Options.Ragged], and extra fields are dropped.
+// The Transform keeps state between rows,
+// so it must not be shared between concurrent pipelines.
+func Derive(name string, value func(*Row) (string, error)) Transform {
+	var header, record []string
+	var out Row
+	return func(row *Row) (*Row, error) {
+		v, err := value(row)
+		if err != nil {
+			return nil, err
+		}
+		if out.idx == nil || !slices.Equal(row.header, header) {
+			header = slices.Clone(row.header)
+			out.index(append(slices.Clone(header), name), KeepLastDuplicate)
+		}
+		row.materialize()
+		record = append(record[:0], row.row[:min(len(row.row), len(header))]...)
+		for len(record) < len(header) {
+			record = append(record, "")
+		}
+		record = append(record, v)
+		out.load(record)
+		out.blank, out.number, out.line = row.blank, row.number, row.line
+		out.scanConf = row.scanConf
+		return &out, nil
+	}
+}
+
+// A RowWriter is a destination for rows, such as a [Writer] or [ShardWriter].
+type RowWriter interface {
+	WriteRow(row *Row) error
+	Close() error
+}
+
+// Pipeline streams rows from Source through Transforms to Sink.
+// Only one row is held in memory at a time.
+type Pipeline struct {
+	Source     Options
+	Transforms []Transform
+	Sink       RowWriter
+}
+
+// Rows returns a sequence of the rows of p.Source after p.Transforms.
+func (p *Pipeline) Rows() iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+	rows:
+		for row, err := range p.Source.Rows() {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, t := range p.Transforms {
+				row, err = t(row)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if row == nil {
+					continue rows
+				}
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Run writes the rows of p.Source after p.Transforms to p.Sink
+// and then closes p.Sink.
+func (p *Pipeline) Run() error {
+	for row, err := range p.Rows() {
+		if err != nil {
+			return err
+		}
+		if err = p.Sink.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return p.Sink.Close()
+}
@@ -0,0 +1,244 @@
+package csv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QuoteMode controls which fields a [Writer] quotes.
+type QuoteMode int8
+
+const (
+	// QuoteMinimal quotes only the fields that need it, as encoding/csv does:
+	// those holding the delimiter, a quote, or a line break,
+	// or beginning with a space.
+	QuoteMinimal QuoteMode = iota
+	// QuoteAll quotes every field, including empty ones.
+	QuoteAll
+	// QuoteNonEmpty quotes every field but empty ones,
+	// which some readers take to be null.
+	QuoteNonEmpty
+	// QuoteNever never quotes fields. A field that needs quotes
+	// is an error wrapping [ErrQuoteNeeded].
+	QuoteNever
+)
+
+// ErrQuoteNeeded is returned for a field that needs quotes
+// with [QuoteNever].
+var ErrQuoteNeeded = errors.New("csv: field needs quotes")
+
+// recordWriter writes records like encoding/csv.Writer,
+// but with a QuoteMode for each column.
+type recordWriter struct {
+	w       *bufio.Writer
+	comma   rune
+	quote   rune
+	useCRLF bool
+	// quoting returns the QuoteMode of column i.
+	quoting func(i int) QuoteMode
+	// formulaPrefix, if not empty, is prepended to formulas.
+	formulaPrefix string
+}
+
+func newRecordWriter(w io.Writer) *recordWriter {
+	return &recordWriter{
+		w:     bufio.NewWriter(w),
+		comma: ',',
+		quote: '"',
+	}
+}
+
+// Write writes a single record, quoting its fields as needed.
+func (w *recordWriter) Write(record []string) error {
+	if !validDelim(w.comma) || !validDelim(w.quote) || w.comma == w.quote {
+		return errInvalidDelim
+	}
+	if w.quoting != nil {
+		// Check before writing, so that no partial record is written.
+		for n, field := range record {
+			field = w.defuse(field)
+			if w.quoting(n) == QuoteNever && w.fieldNeedsQuotes(field) {
+				return fmt.Errorf("%w: %q", ErrQuoteNeeded, field)
+			}
+		}
+	}
+	for n, field := range record {
+		if n > 0 {
+			if _, err := w.w.WriteRune(w.comma); err != nil {
+				return err
+			}
+		}
+		field = w.defuse(field)
+		mode := QuoteMinimal
+		if w.quoting != nil {
+			mode = w.quoting(n)
+		}
+		quote := false
+		switch mode {
+		case QuoteMinimal:
+			quote = w.fieldNeedsQuotes(field)
+		case QuoteAll:
+			quote = true
+		case QuoteNonEmpty:
+			quote = field != ""
+		}
+		if !quote {
+			if _, err := w.w.WriteString(field); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.writeQuoted(field); err != nil {
+			return err
+		}
+	}
+	return w.endLine()
+}
+
+// endLine writes a line ending.
+func (w *recordWriter) endLine() error {
+	if w.useCRLF {
+		_, err := w.w.WriteString("\r\n")
+		return err
+	}
+	return w.w.WriteByte('\n')
+}
+
+// writeLines writes each line of text, with prefix if it is not 0.
+func (w *recordWriter) writeLines(prefix rune, text string) error {
+	text = strings.TrimSuffix(text, "\n")
+	for _, line := range strings.Split(text, "\n") {
+		if prefix != 0 {
+			if _, err := w.w.WriteRune(prefix); err != nil {
+				return err
+			}
+		}
+		if _, err := w.w.WriteString(strings.TrimSuffix(line, "\r")); err != nil {
+			return err
+		}
+		if err := w.endLine(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defuse returns field with w.formulaPrefix prepended
+// if a spreadsheet could take field for a formula.
+func (w *recordWriter) defuse(field string) string {
+	if w.formulaPrefix == "" || field == "" || !strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return field
+	}
+	if _, err := strconv.ParseFloat(field, 64); err == nil {
+		// Leave numbers like "-1" alone.
+		return field
+	}
+	return w.formulaPrefix + field
+}
+
+// writeQuoted writes field in quotes, doubling the quotes in it.
+func (w *recordWriter) writeQuoted(field string) error {
+	if _, err := w.w.WriteRune(w.quote); err != nil {
+		return err
+	}
+	specials := "\"\r\n"
+	if w.quote != '"' {
+		specials = string(w.quote) + "\r\n"
+	}
+	for len(field) > 0 {
+		// Search for special characters.
+		i := strings.IndexAny(field, specials)
+		if i < 0 {
+			i = len(field)
+		}
+		// Copy verbatim everything before the special character.
+		if _, err := w.w.WriteString(field[:i]); err != nil {
+			return err
+		}
+		field = field[i:]
+		// Encode the special character.
+		if len(field) > 0 {
+			var err error
+			n := 1
+			switch field[0] {
+			case '\r':
+				if !w.useCRLF {
+					err = w.w.WriteByte('\r')
+				}
+			case '\n':
+				if w.useCRLF {
+					_, err = w.w.WriteString("\r\n")
+				} else {
+					err = w.w.WriteByte('\n')
+				}
+			default:
+				// The quote, which is doubled.
+				n = utf8.RuneLen(w.quote)
+				if _, err = w.w.WriteRune(w.quote); err == nil {
+					_, err = w.w.WriteRune(w.quote)
+				}
+			}
+			field = field[n:]
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err := w.w.WriteRune(w.quote)
+	return err
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+// To check if an error occurred during Flush, call Error.
+func (w *recordWriter) Flush() {
+	w.w.Flush()
+}
+
+// Error reports any error that has occurred during
+// a previous Write or Flush.
+func (w *recordWriter) Error() error {
+	_, err := w.w.Write(nil)
+	return err
+}
+
+// fieldNeedsQuotes reports whether our field must be enclosed in quotes.
+// Fields with a Comma, fields with a quote or newline, and
+// fields which start with a space must be enclosed in quotes.
+// We used to quote empty strings, but we do not anymore (as of Go 1.4).
+// The two representations should be equivalent, but Postgres distinguishes
+// quoted vs non-quoted empty string during database imports, and it has
+// an option to force the quoted behavior for non-quoted CSV but it has
+// no option to force the non-quoted behavior for quoted CSV, making
+// CSV with quoted empty strings strictly less useful.
+// Not quoting the empty string also makes this package match the behavior
+// of Microsoft Excel and Google Drive.
+// For Postgres, quote the data terminating string `\.`.
+func (w *recordWriter) fieldNeedsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if field == `\.` {
+		return true
+	}
+	if w.comma < utf8.RuneSelf && w.quote < utf8.RuneSelf {
+		for i := 0; i < len(field); i++ {
+			c := field[i]
+			if c == '\n' || c == '\r' || c == byte(w.quote) || c == byte(w.comma) {
+				return true
+			}
+		}
+	} else {
+		if strings.ContainsRune(field, w.comma) || strings.ContainsRune(field, w.quote) ||
+			strings.ContainsAny(field, "\r\n") {
+			return true
+		}
+	}
+	r1, _ := utf8.DecodeRuneInString(field)
+	return unicode.IsSpace(r1)
+}
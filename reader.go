@@ -0,0 +1,811 @@
+// Portions of this file are adapted from encoding/csv.
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var errInvalidDelim = errors.New("csv: invalid field or comment delimiter")
+
+func validDelim(r rune) bool {
+	return r != 0 && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+// reader is a CSV record parser.
+// It behaves like encoding/csv.Reader with ReuseRecord set,
+// except that it can report blank lines instead of skipping them.
+type reader struct {
+	comma rune
+	// sep is the field delimiter. If nil, it is set to comma
+	// by the first read. It may be more than one rune.
+	sep              []byte
+	quote            rune
+	comment          rune
+	onComment        func(string)
+	fieldsPerRecord  int
+	lazyQuotes       bool
+	trimLeadingSpace bool
+	keepBlankLines   bool
+	lazyStrings      bool
+	strict           bool
+	requireCRLF      bool
+	trimSpace        bool
+
+	// term, if not nil, ends each line in place of \n.
+	// A newline directly after term is part of the line ending.
+	term []byte
+	// termCR reports whether term was set by detectCR.
+	termCR bool
+	// sepFixed reports whether the delimiter was set explicitly,
+	// so a sep= line does not change it,
+	// and sepHinted whether sep was set by a sep= line.
+	sepFixed, sepHinted bool
+	// midstream reports whether the input starts partway through a file,
+	// so its first line is not sniffed for a terminator or delimiter hint.
+	midstream bool
+	// delimErr is errInvalidDelim if the delimiters
+	// checked by checkDelims cannot be told apart.
+	delimErr error
+
+	// nulls are field values to replace with empty strings.
+	nulls []string
+
+	// norm is applied to the fields of each record.
+	norm normalizer
+	// normBuffer is swapped with recordBuffer by normalizeFields.
+	normBuffer []byte
+
+	// keep, if not nil, reports which columns to keep.
+	// Other columns are parsed but left out of records.
+	keep []bool
+
+	// nfields is the number of fields in the last record read,
+	// including fields left out by keep.
+	nfields int
+
+	r *bufio.Reader
+
+	// records, if not nil, is read from instead of parsing r.
+	records RecordReader
+
+	// data, if not nil, is read from instead of r.
+	// It holds the unread part of an in-memory source,
+	// which must not be modified.
+	data []byte
+	mem  bool
+
+	// numLine is the current line being read in the CSV file.
+	numLine int
+
+	// offset is the input stream byte offset of the current reader position.
+	offset int64
+
+	// lfCol is the column of the \n ending the last line read
+	// if requireCRLF is set and it is not preceded by \r, or else 0.
+	lfCol int
+
+	// recordLine is the line where the last record read started.
+	recordLine int
+
+	// blank reports whether the last record read was a blank line.
+	// It is only set if keepBlankLines is true.
+	blank bool
+
+	// rawBuffer is a line buffer only used by the readLine method.
+	rawBuffer []byte
+
+	// recordBuffer holds the unescaped fields, one after another.
+	// The fields can be accessed by using the indexes in fieldIndexes.
+	recordBuffer []byte
+
+	// fieldIndexes is an index of fields inside recordBuffer.
+	// The i'th field ends at offset fieldIndexes[i] in recordBuffer.
+	fieldIndexes []int
+
+	// fieldPositions is an index of field positions for the
+	// last record returned by read.
+	fieldPositions []position
+
+	// lastRecord is a record cache reused between calls to read.
+	lastRecord []string
+}
+
+// position holds the position of a field in the current line.
+type position struct {
+	line, col int
+}
+
+// newReader returns a reader for r with a read buffer of at least size bytes.
+// If size is not positive, a default size is used.
+func newReader(r io.Reader, size int) *reader {
+	if mr, ok := r.(*memReader); ok {
+		return &reader{
+			comma: ',',
+			quote: '"',
+			data:  mr.unread(),
+			mem:   true,
+		}
+	}
+	return &reader{
+		comma: ',',
+		quote: '"',
+		r:     newBufioReader(r, size),
+	}
+}
+
+func newBufioReader(r io.Reader, size int) *bufio.Reader {
+	if size <= 0 {
+		return bufio.NewReader(r)
+	}
+	return bufio.NewReaderSize(r, size)
+}
+
+// reset prepares r to parse src, keeping its configuration and buffers.
+func (r *reader) reset(src io.Reader, size int) {
+	if mr, ok := src.(*memReader); ok {
+		r.data, r.mem = mr.unread(), true
+	} else {
+		r.data, r.mem = nil, false
+		if r.r == nil {
+			r.r = newBufioReader(src, size)
+		} else {
+			r.r.Reset(src)
+		}
+	}
+	if r.termCR {
+		r.term, r.termCR = nil, false
+	}
+	if r.sepHinted {
+		r.sep, r.sepHinted = utf8.AppendRune(nil, r.comma), false
+	}
+	r.numLine = 0
+	r.offset = 0
+	r.recordLine = 0
+	r.blank = false
+	r.fieldsPerRecord = 0
+	r.keepBlankLines = false
+	r.lazyStrings = false
+	r.keep = nil
+	r.nulls = nil
+}
+
+// memReader is an io.Reader over memory that the parser may read in place.
+type memReader struct {
+	*bytes.Reader
+	data []byte
+}
+
+// unread returns the unread portion of mr and marks it as read.
+func (mr *memReader) unread() []byte {
+	data := mr.data[len(mr.data)-mr.Len():]
+	mr.Seek(0, io.SeekEnd)
+	return data
+}
+
+// read reads one record from r.
+// The returned slice is shared between calls to read.
+func (r *reader) read() (record []string, err error) {
+	record, err = r.readRecord(r.lastRecord)
+	r.lastRecord = record
+	return record, err
+}
+
+// readLine reads the next line (with the trailing endline).
+// If EOF is hit without a trailing endline, it will be omitted.
+// If some bytes were read, then the error is never io.EOF.
+// The result is only valid until the next call to readLine.
+func (r *reader) readLine() ([]byte, error) {
+	if r.offset == 0 && !r.midstream {
+		return r.readFirstLine()
+	}
+	return r.nextLine()
+}
+
+// nextLine reads the next line of the input,
+// normalizing its line ending.
+func (r *reader) nextLine() ([]byte, error) {
+	var (
+		line []byte
+		err  error
+	)
+	if r.term != nil {
+		line, err = r.readTermLine()
+	} else if r.mem {
+		line, err = r.readMemLine()
+	} else {
+		line, err = r.r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			r.rawBuffer = append(r.rawBuffer[:0], line...)
+			for err == bufio.ErrBufferFull {
+				line, err = r.r.ReadSlice('\n')
+				r.rawBuffer = append(r.rawBuffer, line...)
+			}
+			line = r.rawBuffer
+		}
+	}
+	readSize := len(line)
+	if readSize > 0 && err == io.EOF {
+		err = nil
+		// For backwards compatibility, drop trailing \r before EOF.
+		if r.term == nil && !r.strict && line[readSize-1] == '\r' {
+			line = line[:readSize-1]
+		}
+	}
+	r.numLine++
+	r.offset += int64(readSize)
+	r.lfCol = 0
+	if n := len(line); r.requireCRLF && n > 0 && line[n-1] == '\n' && (n < 2 || line[n-2] != '\r') {
+		r.lfCol = n
+	}
+	if r.term != nil {
+		return line, err
+	}
+	// Normalize \r\n to \n on all input lines.
+	if n := len(line); n >= 2 && line[n-2] == '\r' && line[n-1] == '\n' {
+		if r.mem {
+			// Don't modify the source.
+			r.rawBuffer = append(r.rawBuffer[:0], line...)
+			line = r.rawBuffer
+		}
+		line[n-2] = '\n'
+		line = line[:n-1]
+	}
+	return line, err
+}
+
+// readFirstLine reads the first line of the input,
+// sniffing its line terminator and skipping any delimiter hint,
+// so that readLine need not check for them on every line.
+func (r *reader) readFirstLine() ([]byte, error) {
+	if r.numLine == 0 {
+		r.detectCR()
+	}
+	line, err := r.nextLine()
+	if r.offset == 0 {
+		return line, err
+	}
+	if err == nil {
+		if sep, ok := parseSepHint(line); ok {
+			// Skip the delimiter hint written for Excel,
+			// and use its delimiter unless one was set.
+			if !r.sepFixed && sep != r.quote && sep != r.comment {
+				r.sep, r.sepHinted = utf8.AppendRune(nil, sep), true
+			}
+			return r.readLine()
+		}
+	}
+	return line, err
+}
+
+// detectCR sets r.term to \r if the start of the input
+// has carriage returns but no newlines,
+// as written by classic Mac OS.
+func (r *reader) detectCR() {
+	if r.term != nil || r.strict {
+		return
+	}
+	const size = 4096
+	var head []byte
+	if r.mem {
+		head = r.data[:min(len(r.data), size)]
+	} else {
+		// Read until a newline, so as not to block longer than readLine.
+		for {
+			var err error
+			head, err = r.r.Peek(min(r.r.Buffered()+1, size))
+			if err != nil || len(head) == size || bytes.IndexByte(head, '\n') >= 0 {
+				break
+			}
+		}
+	}
+	if bytes.IndexByte(head, '\r') >= 0 && bytes.IndexByte(head, '\n') < 0 {
+		r.term, r.termCR = []byte("\r"), true
+	}
+}
+
+// parseSepHint returns the delimiter of line if it is a line like "sep=;",
+// which tells Excel the delimiter of a file.
+func parseSepHint(line []byte) (rune, bool) {
+	rest, ok := bytes.CutPrefix(bytes.TrimRight(line, "\r\n"), []byte("sep="))
+	if !ok {
+		return 0, false
+	}
+	sep, size := utf8.DecodeRune(rest)
+	return sep, size == len(rest) && validDelim(sep)
+}
+
+// readMemLine returns the next line of r.data in place.
+func (r *reader) readMemLine() ([]byte, error) {
+	i := bytes.IndexByte(r.data, '\n')
+	if i < 0 {
+		line := r.data
+		r.data = nil
+		return line, io.EOF
+	}
+	line := r.data[:i+1]
+	r.data = r.data[i+1:]
+	return line, nil
+}
+
+// readTermLine reads up to and including the next r.term
+// into r.rawBuffer.
+func (r *reader) readTermLine() ([]byte, error) {
+	last := r.term[len(r.term)-1]
+	r.rawBuffer = r.rawBuffer[:0]
+	for {
+		var (
+			b   []byte
+			err error
+		)
+		if r.mem {
+			if i := bytes.IndexByte(r.data, last); i >= 0 {
+				b, r.data = r.data[:i+1], r.data[i+1:]
+			} else {
+				b, r.data, err = r.data, nil, io.EOF
+			}
+		} else {
+			b, err = r.r.ReadSlice(last)
+			if err == bufio.ErrBufferFull {
+				err = nil
+			}
+		}
+		r.rawBuffer = append(r.rawBuffer, b...)
+		if err != nil {
+			return r.rawBuffer, err
+		}
+		if bytes.HasSuffix(r.rawBuffer, r.term) {
+			break
+		}
+	}
+	// Take a newline after the terminator as part of the line ending.
+	var next []byte
+	if r.mem {
+		next = r.data
+	} else if next, _ = r.r.Peek(1); bytes.HasPrefix(next, []byte("\r")) {
+		next, _ = r.r.Peek(2)
+	}
+	n := 0
+	if bytes.HasPrefix(next, []byte("\n")) {
+		n = 1
+	} else if bytes.HasPrefix(next, []byte("\r\n")) {
+		n = 2
+	}
+	r.rawBuffer = append(r.rawBuffer, next[:n]...)
+	if r.mem {
+		r.data = r.data[n:]
+	} else {
+		r.r.Discard(n)
+	}
+	return r.rawBuffer, nil
+}
+
+// lengthNL reports the number of bytes for the trailing \n,
+// or for the trailing r.term and any newline after it.
+func (r *reader) lengthNL(b []byte) int {
+	if r.term != nil {
+		return r.lengthTerm(b)
+	}
+	if len(b) > 0 && b[len(b)-1] == '\n' {
+		return 1
+	}
+	return 0
+}
+
+// lengthTerm reports the number of bytes
+// for the trailing r.term and any newline after it.
+func (r *reader) lengthTerm(b []byte) int {
+	for _, nl := range []string{"\r\n", "\n", ""} {
+		if bytes.HasSuffix(b, []byte(nl)) && bytes.HasSuffix(b[:len(b)-len(nl)], r.term) {
+			return len(nl) + len(r.term)
+		}
+	}
+	return 0
+}
+
+// nextRune returns the next rune in b or utf8.RuneError.
+func nextRune(b []byte) rune {
+	r, _ := utf8.DecodeRune(b)
+	return r
+}
+
+// keeps reports whether the field in column n should be kept.
+func (r *reader) keeps(n int) bool {
+	return r.keep == nil || (n < len(r.keep) && r.keep[n])
+}
+
+// endField records the end of the field that began
+// at offset start in recordBuffer.
+func (r *reader) endField(start int, pos position) {
+	n := r.nfields
+	r.nfields++
+	if r.keep != nil && !r.keeps(n) {
+		r.recordBuffer = r.recordBuffer[:start]
+		return
+	}
+	r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer))
+	r.fieldPositions = append(r.fieldPositions, pos)
+}
+
+// trimFields removes leading and trailing white space
+// from the fields in r.recordBuffer.
+func (r *reader) trimFields() {
+	start, n := 0, 0
+	for i, end := range r.fieldIndexes {
+		n += copy(r.recordBuffer[n:], bytes.TrimSpace(r.recordBuffer[start:end]))
+		start = end
+		r.fieldIndexes[i] = n
+	}
+	r.recordBuffer = r.recordBuffer[:n]
+}
+
+// nullFields empties the fields in r.recordBuffer
+// that are one of r.nulls.
+func (r *reader) nullFields() {
+	start, n := 0, 0
+	for i, end := range r.fieldIndexes {
+		field := r.recordBuffer[start:end]
+		for _, null := range r.nulls {
+			if string(field) == null {
+				field = nil
+				break
+			}
+		}
+		n += copy(r.recordBuffer[n:], field)
+		start = end
+		r.fieldIndexes[i] = n
+	}
+	r.recordBuffer = r.recordBuffer[:n]
+}
+
+// readSourceRecord reads a record from r.records.
+func (r *reader) readSourceRecord(dst []string) ([]string, error) {
+	r.blank = false
+	for {
+		record, err := r.records.Read()
+		if err != nil && len(record) == 0 {
+			return nil, err
+		}
+		r.numLine++
+		if fp, ok := r.records.(interface{ FieldPos(int) (int, int) }); ok && len(record) > 0 {
+			r.numLine, _ = fp.FieldPos(0)
+		}
+		r.recordLine = r.numLine
+		r.recordBuffer = r.recordBuffer[:0]
+		r.fieldIndexes = r.fieldIndexes[:0]
+		r.fieldPositions = r.fieldPositions[:0]
+		r.nfields = len(record)
+		if r.keep != nil {
+			dst = dst[:0]
+			for i, field := range record {
+				if r.keeps(i) {
+					dst = append(dst, field)
+				}
+			}
+			record = dst
+		}
+		if r.trimSpace || r.nulls != nil || r.norm.mode != UnicodeAsIs {
+			if r.keep == nil {
+				dst = append(dst[:0], record...)
+				record = dst
+			}
+			for i, field := range record {
+				if r.trimSpace {
+					field = strings.TrimSpace(field)
+				}
+				if slices.Contains(r.nulls, field) {
+					field = ""
+				}
+				record[i] = r.norm.string(field)
+			}
+		}
+		for _, field := range record {
+			r.recordBuffer = append(r.recordBuffer, field...)
+			r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer))
+		}
+		if r.nfields == 0 && err == nil {
+			if !r.keepBlankLines {
+				continue
+			}
+			r.blank = true
+			return dst[:0], nil
+		}
+		n := r.nfields
+		if r.fieldsPerRecord > 0 {
+			if n != r.fieldsPerRecord && err == nil {
+				err = &csv.ParseError{
+					StartLine: r.recordLine,
+					Line:      r.recordLine,
+					Column:    1,
+					Err:       csv.ErrFieldCount,
+				}
+			}
+		} else if r.fieldsPerRecord == 0 {
+			r.fieldsPerRecord = n
+		}
+		if r.lazyStrings {
+			return dst[:0], err
+		}
+		return record, err
+	}
+}
+
+// checkDelims sets r.sep to r.comma if it is not set,
+// and sets r.delimErr if the field delimiter, quote, comment,
+// and terminator of r cannot be told apart.
+// It is called once, when r is configured.
+func (r *reader) checkDelims() {
+	r.delimErr = nil
+	if r.sep == nil {
+		if !validDelim(r.comma) {
+			r.delimErr = errInvalidDelim
+			return
+		}
+		r.sep = utf8.AppendRune(nil, r.comma)
+	}
+	if len(r.sep) == 0 || !utf8.Valid(r.sep) || bytes.ContainsAny(r.sep, "\r\n") ||
+		bytes.ContainsRune(r.sep, r.quote) || (r.comment != 0 && nextRune(r.sep) == r.comment) {
+		r.delimErr = errInvalidDelim
+		return
+	}
+	if r.term != nil && (len(r.term) == 0 || !utf8.Valid(r.term) || bytes.IndexByte(r.term, '\n') >= 0 ||
+		bytes.ContainsRune(r.term, r.quote) || bytes.Contains(r.term, r.sep) || bytes.Contains(r.sep, r.term)) {
+		r.delimErr = errInvalidDelim
+		return
+	}
+	if r.comment == r.quote || !validDelim(r.quote) || (r.comment != 0 && !validDelim(r.comment)) {
+		r.delimErr = errInvalidDelim
+	}
+}
+
+func (r *reader) readRecord(dst []string) ([]string, error) {
+	if r.records != nil {
+		return r.readSourceRecord(dst)
+	}
+	if r.delimErr != nil {
+		return nil, r.delimErr
+	}
+
+	// Read line (automatically skipping past empty lines and any comments).
+	var line []byte
+	var errRead error
+	r.blank = false
+	for errRead == nil {
+		line, errRead = r.readLine()
+		if r.comment != 0 && nextRune(line) == r.comment {
+			if r.onComment != nil {
+				r.onComment(string(line[:len(line)-r.lengthNL(line)]))
+			}
+			line = nil
+			continue // Skip comment lines
+		}
+		if errRead == nil && len(line) == r.lengthNL(line) {
+			if r.keepBlankLines {
+				r.blank = true
+				r.recordLine = r.numLine
+				r.recordBuffer = r.recordBuffer[:0]
+				r.fieldIndexes = r.fieldIndexes[:0]
+				r.fieldPositions = r.fieldPositions[:0]
+				return dst[:0], nil
+			}
+			line = nil
+			continue // Skip empty lines
+		}
+		break
+	}
+	if errRead == io.EOF {
+		return nil, errRead
+	}
+
+	// Parse each field in the record.
+	var err error
+	quoteLen := utf8.RuneLen(r.quote)
+	commaLen := len(r.sep)
+	// The quote and delimiter are usually single bytes,
+	// which are searched for without decoding runes.
+	quoteByte, sepByte := -1, -1
+	if r.quote < utf8.RuneSelf {
+		quoteByte = int(r.quote)
+	}
+	if commaLen == 1 {
+		sepByte = int(r.sep[0])
+	}
+	recLine := r.numLine // Starting line for record
+	r.recordLine = recLine
+	r.recordBuffer = r.recordBuffer[:0]
+	r.fieldIndexes = r.fieldIndexes[:0]
+	r.fieldPositions = r.fieldPositions[:0]
+	r.nfields = 0
+	pos := position{line: r.numLine, col: 1}
+parseField:
+	for {
+		if r.trimLeadingSpace {
+			i := bytes.IndexFunc(line, func(r rune) bool {
+				return !unicode.IsSpace(r)
+			})
+			if i < 0 {
+				i = len(line)
+				pos.col -= r.lengthNL(line)
+			}
+			line = line[i:]
+			pos.col += i
+		}
+		quoted := len(line) > 0 && int(line[0]) == quoteByte
+		if quoteByte < 0 {
+			quoted = nextRune(line) == r.quote
+		}
+		if !quoted {
+			// Non-quoted string field
+			var i int
+			if sepByte >= 0 {
+				i = bytes.IndexByte(line, byte(sepByte))
+			} else {
+				i = bytes.Index(line, r.sep)
+			}
+			field := line
+			if i >= 0 {
+				field = field[:i]
+			} else {
+				field = field[:len(field)-r.lengthNL(field)]
+			}
+			// Check to make sure a quote does not appear in field.
+			if !r.lazyQuotes {
+				var j int
+				if quoteByte >= 0 {
+					j = bytes.IndexByte(field, byte(quoteByte))
+				} else {
+					j = bytes.IndexRune(field, r.quote)
+				}
+				if j >= 0 {
+					col := pos.col + j
+					err = &csv.ParseError{StartLine: recLine, Line: r.numLine, Column: col, Err: csv.ErrBareQuote}
+					break parseField
+				}
+			}
+			if r.strict {
+				if j := bytes.IndexByte(field, '\r'); j >= 0 {
+					err = &csv.ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col + j, Err: ErrBareCR}
+					break parseField
+				}
+			}
+			start := len(r.recordBuffer)
+			if r.keeps(r.nfields) {
+				r.recordBuffer = append(r.recordBuffer, field...)
+			}
+			r.endField(start, pos)
+			if i >= 0 {
+				line = line[i+commaLen:]
+				pos.col += i + commaLen
+				continue parseField
+			}
+			break parseField
+		} else {
+			// Quoted string field
+			fieldPos := pos
+			start := len(r.recordBuffer)
+			line = line[quoteLen:]
+			pos.col += quoteLen
+			for {
+				var i int
+				if quoteByte >= 0 {
+					i = bytes.IndexByte(line, byte(quoteByte))
+				} else {
+					i = bytes.IndexRune(line, r.quote)
+				}
+				if i >= 0 {
+					// Hit next quote.
+					r.recordBuffer = append(r.recordBuffer, line[:i]...)
+					line = line[i+quoteLen:]
+					pos.col += i + quoteLen
+					switch {
+					case len(line) > 0 && int(line[0]) == quoteByte,
+						quoteByte < 0 && nextRune(line) == r.quote:
+						// `""` sequence (append quote).
+						r.recordBuffer = utf8.AppendRune(r.recordBuffer, r.quote)
+						line = line[quoteLen:]
+						pos.col += quoteLen
+					case len(line) > 0 && int(line[0]) == sepByte,
+						sepByte < 0 && bytes.HasPrefix(line, r.sep):
+						// `",` sequence (end of field).
+						line = line[commaLen:]
+						pos.col += commaLen
+						r.endField(start, fieldPos)
+						continue parseField
+					case r.lengthNL(line) == len(line):
+						// `"\n` sequence (end of line).
+						r.endField(start, fieldPos)
+						break parseField
+					case r.lazyQuotes:
+						// `"` sequence (bare quote).
+						r.recordBuffer = utf8.AppendRune(r.recordBuffer, r.quote)
+					default:
+						// `"*` sequence (invalid non-escaped quote).
+						err = &csv.ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col - quoteLen, Err: csv.ErrQuote}
+						break parseField
+					}
+				} else if len(line) > 0 {
+					// Hit end of line (copy all data so far).
+					r.recordBuffer = append(r.recordBuffer, line...)
+					if errRead != nil {
+						break parseField
+					}
+					pos.col += len(line)
+					line, errRead = r.readLine()
+					if len(line) > 0 {
+						pos.line++
+						pos.col = 1
+					}
+					if errRead == io.EOF {
+						errRead = nil
+					}
+				} else {
+					// Abrupt end of file (EOF or error).
+					if !r.lazyQuotes && errRead == nil {
+						err = &csv.ParseError{StartLine: recLine, Line: pos.line, Column: pos.col, Err: csv.ErrQuote}
+						break parseField
+					}
+					r.endField(start, fieldPos)
+					break parseField
+				}
+			}
+		}
+	}
+	if err == nil && r.lfCol > 0 {
+		err = &csv.ParseError{StartLine: recLine, Line: r.numLine, Column: r.lfCol, Err: ErrLineEnding}
+	}
+	if err == nil {
+		err = errRead
+	}
+	if r.trimSpace {
+		r.trimFields()
+	}
+	if r.nulls != nil {
+		r.nullFields()
+	}
+	if r.norm.mode != UnicodeAsIs {
+		r.normalizeFields()
+	}
+
+	dst = dst[:0]
+	if !r.lazyStrings {
+		// Create a single string and create slices out of it.
+		// This pins the memory of the fields together, but allocates once.
+		str := string(r.recordBuffer) // Convert to string once to batch allocations
+		if cap(dst) < len(r.fieldIndexes) {
+			dst = make([]string, len(r.fieldIndexes))
+		}
+		dst = dst[:len(r.fieldIndexes)]
+		var preIdx int
+		for i, idx := range r.fieldIndexes {
+			dst[i] = str[preIdx:idx]
+			preIdx = idx
+		}
+	}
+
+	// Check or update the expected fields per record.
+	n := r.nfields
+	if r.fieldsPerRecord > 0 {
+		if n != r.fieldsPerRecord && err == nil {
+			err = &csv.ParseError{
+				StartLine: recLine,
+				Line:      recLine,
+				Column:    1,
+				Err:       csv.ErrFieldCount,
+			}
+		}
+	} else if r.fieldsPerRecord == 0 {
+		r.fieldsPerRecord = n
+	}
+	return dst, err
+}
@@ -0,0 +1,86 @@
+package csv
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+)
+
+// Head returns clones of the first n rows of o.
+func Head(o Options, n int) ([]*Row, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if o.Limit == 0 || n < o.Limit {
+		o.Limit = n
+	}
+	rows := make([]*Row, 0, n)
+	for row, err := range o.Rows() {
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row.Clone())
+	}
+	return rows, nil
+}
+
+// Tail returns clones of the last n rows of o.
+// Only n rows are kept in memory at a time,
+// so Tail can be used on sources of any length.
+func Tail(o Options, n int) ([]*Row, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	ring := make([]*Row, 0, n)
+	next := 0
+	for row, err := range o.Rows() {
+		if err != nil {
+			return nil, err
+		}
+		if len(ring) < n {
+			ring = append(ring, row.Clone())
+			continue
+		}
+		ring[next] = row.Clone()
+		next = (next + 1) % n
+	}
+	rows := make([]*Row, 0, len(ring))
+	rows = append(rows, ring[next:]...)
+	rows = append(rows, ring[:next]...)
+	return rows, nil
+}
+
+// Sample returns clones of n rows of o chosen uniformly at random,
+// in the order they appear in o.
+// It reads o in a single pass and keeps only n rows in memory.
+// Random numbers are drawn from src, or from a randomly seeded source if src is nil.
+func Sample(o Options, n int, src rand.Source) ([]*Row, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	var rng *rand.Rand
+	if src != nil {
+		rng = rand.New(src)
+	} else {
+		rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	rows := make([]*Row, 0, n)
+	seen := 0
+	for row, err := range o.Rows() {
+		if err != nil {
+			return nil, err
+		}
+		seen++
+		if len(rows) < n {
+			rows = append(rows, row.Clone())
+			continue
+		}
+		if i := rng.IntN(seen); i < n {
+			rows[i] = row.Clone()
+		}
+	}
+	slices.SortFunc(rows, func(a, b *Row) int {
+		return cmp.Compare(a.number, b.number)
+	})
+	return rows, nil
+}
@@ -0,0 +1,1063 @@
+package csv
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"math/big"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scan returns an iterator reading from o.
+// On each iteration it scans the row into v.
+// See [Row.Scan].
+func Scan[T any](o Options, v *T) iter.Seq[error] {
+	return Bind[T](nil).Scan(o, v)
+}
+
+// ScanAll returns a slice of all objects read from o or an error.
+// See [Row.Scan].
+func ScanAll[T any](o Options) ([]T, error) {
+	return Bind[T](nil).ScanAll(o)
+}
+
+// ScanAllInto appends all objects read from o to *dst.
+// If there is an error, *dst is left unchanged,
+// though its elements past its length may be overwritten.
+// See [Row.Scan].
+func ScanAllInto[T any](o Options, dst *[]T) error {
+	return Bind[T](nil).ScanAllInto(o, dst)
+}
+
+// ScanAllParallel is like [ScanAll], but rows are scanned into values
+// by up to workers goroutines while o.Reader is parsed.
+// The order of the rows is preserved.
+// If workers is less than 1, runtime.GOMAXPROCS(0) is used.
+// It can be faster than ScanAll for types that are expensive to scan.
+func ScanAllParallel[T any](o Options, workers int) ([]T, error) {
+	return Bind[T](nil).ScanAllParallel(o, workers)
+}
+
+// parallelBatchSize is the number of rows scanned at a time
+// by each goroutine in [Binder.ScanAllParallel].
+const parallelBatchSize = 256
+
+// ScanAllParallel is like [Binder.ScanAll], but rows are scanned into values
+// by up to workers goroutines. See [ScanAllParallel].
+func (b *Binder[T]) ScanAllParallel(o Options, workers int) ([]T, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	type batch struct {
+		out []T
+		err error
+	}
+	type job struct {
+		rows     []*Row
+		batch    *batch
+		bindings []binding
+	}
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				for i, row := range j.rows {
+					if err := row.scan(reflect.ValueOf(&j.batch.out[i]).Elem(), j.bindings, false); err != nil {
+						j.batch.err = err
+						break
+					}
+				}
+			}
+		}()
+	}
+	var (
+		batches  []*batch
+		bindings []binding
+		n        int
+		err      error
+	)
+	for rows, err2 := range o.Batches(parallelBatchSize) {
+		if err2 != nil {
+			err = err2
+			break
+		}
+		if bindings == nil {
+			bindings = b.bind(rows[0])
+		}
+		bt := &batch{out: make([]T, len(rows))}
+		batches = append(batches, bt)
+		n += len(rows)
+		jobs <- job{rows, bt, bindings}
+	}
+	close(jobs)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	for _, bt := range batches {
+		if bt.err != nil {
+			return nil, bt.err
+		}
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	s := make([]T, 0, max(n, o.RowsHint))
+	for _, bt := range batches {
+		s = append(s, bt.out...)
+	}
+	return s, nil
+}
+
+// Binder scans rows into values of type T.
+// The reflection on T is done once by [Bind],
+// so a Binder can be reused cheaply across many files.
+// A Binder is safe for concurrent use.
+type Binder[T any] struct {
+	fields   []fieldInfo
+	header   []string
+	bindings []binding
+}
+
+// Bind returns a Binder for the type T, which must be a struct,
+// a map from strings to strings, or a slice of strings.
+// If fieldnames is not nil, the mapping of the fields of T to fieldnames
+// is also done once and reused for every file with a matching header.
+// Bind panics if T is not such a type.
+// See [Row.Scan] for how fields are matched to columns.
+func Bind[T any](fieldnames []string) *Binder[T] {
+	t := reflect.TypeFor[T]()
+	if !isScanType(t) {
+		panic(badScanType)
+	}
+	b := &Binder[T]{fields: structFields(t)}
+	var r Row
+	if fieldnames != nil && r.index(fieldnames, RejectDuplicates) == nil {
+		b.header = r.header
+		b.bindings = r.bind(b.fields)
+	}
+	return b
+}
+
+// Scan returns an iterator reading from o.
+// On each iteration it scans the row into v.
+// If a row cannot be scanned, the error is yielded
+// and iteration continues with the next row.
+func (b *Binder[T]) Scan(o Options, v *T) iter.Seq[error] {
+	return func(yield func(error) bool) {
+		s := reflect.ValueOf(v).Elem()
+		var bindings []binding
+		for row, err := range o.Rows() {
+			if err != nil {
+				yield(err)
+				return
+			}
+			if bindings == nil {
+				bindings = b.bind(row)
+			}
+			if !yield(row.scan(s, bindings, false)) {
+				return
+			}
+		}
+	}
+}
+
+// ScanAll returns a slice of all objects read from o or an error.
+func (b *Binder[T]) ScanAll(o Options) ([]T, error) {
+	var s []T
+	if err := b.ScanAllInto(o, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ScanAllInto appends all objects read from o to *dst. See [ScanAllInto].
+func (b *Binder[T]) ScanAllInto(o Options, dst *[]T) error {
+	s := slices.Grow(*dst, max(o.RowsHint, 0))
+	var v T
+	for err := range b.Scan(o, &v) {
+		if err != nil {
+			return err
+		}
+		s = append(s, v)
+	}
+	*dst = s
+	return nil
+}
+
+// ScanRow scans row into v, as with [Row.Scan].
+func (b *Binder[T]) ScanRow(row *Row, v *T) {
+	row.scan(reflect.ValueOf(v).Elem(), b.bind(row), true)
+}
+
+// DecodeRow scans row into v, as with [Row.Decode].
+func (b *Binder[T]) DecodeRow(row *Row, v *T) error {
+	return row.scan(reflect.ValueOf(v).Elem(), b.bind(row), false)
+}
+
+func (b *Binder[T]) bind(row *Row) []binding {
+	if b.header != nil && slices.Equal(row.header, b.header) {
+		return b.bindings
+	}
+	return row.bind(b.fields)
+}
+
+// Scan reflects on the row and sets the appropriate fields of s.
+// If v is a pointer to a map from strings to strings, such as
+// a map[string]string, Scan sets it to a new map of fieldnames to values.
+// If v is a pointer to a slice of strings, Scan sets it
+// to a new slice of the values in column order.
+// If v is not a pointer to a struct, map, or slice, Scan will panic.
+// The struct fields to be scanned into must be exported
+// and have a csv field tag with the name of the field to copy.
+// A tag may list alternative names separated by "|",
+// e.g. `csv:"email|e-mail"`, and the first name present in the row is used.
+//
+// Fields may be strings, booleans, integers, or floating-point numbers,
+// types implementing [encoding.TextUnmarshaler], such as [time.Time],
+// [time.Duration], or pointers to any of these.
+// Times are parsed as RFC 3339 unless the tag option layout lists
+// layouts to try in order, e.g. `csv:"created,layout=2006-01-02|01/02/2006"`.
+// Times without a time zone are in [Options.Location], or in the
+// location named by the tag option tz, e.g. `csv:"created,layout=...,tz=Europe/Paris"`.
+// Durations may be written as by [time.ParseDuration], such as "1h30m",
+// or as a clock, such as "1:30:00", which may be required
+// with the tag option duration=go or duration=clock. Values are parsed as by the strconv package.
+// Fields may also be [big.Int], [big.Float], [big.Rat], or types implementing
+// [DecimalUnmarshaler], for values that must not be rounded to a float64.
+// Types implementing [sql.Scanner], such as [sql.NullString],
+// are scanned from strings.
+// A big.Float has enough precision for every digit of the value,
+// unless the tag option prec sets its precision in bits.
+// Byte slices are decoded from base64, with optional padding, or from
+// the encoding named by the tag option encoding=base64url or encoding=hex.
+// With the tag option sep, e.g. `csv:"tags,sep=|"`, a slice is scanned
+// from a list of values separated by sep, with space around them trimmed.
+// With the tag option kv, e.g. `csv:"attrs,kv=;"`, a map is scanned
+// from key=value pairs separated by kv, such as "a=1;b=2".
+// The tag option kvsep sets the separator of keys and values.
+// With the tag option json, e.g. `csv:"metadata,json"`, a field of any type,
+// such as a map, slice, or struct, is unmarshaled from JSON with [json.Unmarshal].
+// Other slice fields are scanned from every column with their name,
+// which may be repeated, or which may contain "*" as a wildcard,
+// e.g. `csv:"item_*"` for the columns item_1, item_2, and so on.
+// A map[string]string field with the tag `csv:",remain"` receives
+// the columns not scanned into other fields by fieldname.
+// An empty value, or one of [Options.NullValues], sets a field to its
+// zero value, which is nil for pointers. Fields of other types are ignored.
+// When writing with [Writer.WriteStruct], the tag option format
+// formats a field with fmt.Sprintf, e.g. `csv:"amount,format=%.2f"`,
+// and the tag option omitempty writes a zero value as an empty string,
+// e.g. `csv:"discount,omitempty"`. Types implementing [Marshaler]
+// are formatted by their MarshalCSV method.
+// Options for a field may follow its names after commas,
+// e.g. `csv:"active,true=Y|yes,false=N|no"`; see [Options.TrueValues]
+// and [Options.NumberFormat].
+// A value that cannot be parsed leaves its field unchanged;
+// use [Row.Decode] to learn of such values.
+//
+// The mapping of fields for the type of v is cached between rows.
+func (r *Row) Scan(v any) {
+	s := r.scanTarget(v)
+	r.scan(s, r.scanBindings, true)
+}
+
+// Decode is like [Row.Scan], but if a value cannot be parsed,
+// it returns a *[ScanError], and the fields after it are left unchanged.
+func (r *Row) Decode(v any) error {
+	s := r.scanTarget(v)
+	return r.scan(s, r.scanBindings, false)
+}
+
+// scanTarget returns the value v points to,
+// after caching the mapping of fields for its type.
+func (r *Row) scanTarget(v any) reflect.Value {
+	s := scanTarget(v)
+	if t := s.Type(); t != r.scanType {
+		r.scanBindings = r.bind(structFields(t))
+		r.scanType = t
+	}
+	return s
+}
+
+// A ScanError records a value that could not be scanned into a struct field.
+type ScanError struct {
+	// Line is the line where the row begins, as with [Row.Line].
+	// Row is the number of the row, as with [Row.Number].
+	Line, Row int
+	// Column is the fieldname of the column.
+	Column string
+	// Value is the value that could not be scanned.
+	Value string
+	// Err is the error from parsing Value.
+	Err error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("line %d, column %q: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+const badScanType = "must scan into pointer to struct, map of strings, or slice of strings"
+
+// scanTarget returns the value pointed to by v.
+func scanTarget(v any) reflect.Value {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || !isScanType(rv.Type().Elem()) {
+		panic(badScanType)
+	}
+	return rv.Elem()
+}
+
+// isScanType reports whether t is a struct, a map from strings
+// to strings, or a slice of strings, which can be scanned into.
+func isScanType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// fieldInfo describes a struct field that can be scanned into.
+type fieldInfo struct {
+	// index is the index of the field in its struct.
+	index int
+	// names are the fieldnames the field may be scanned from.
+	names []string
+	// decode sets the field from a value.
+	decode decoder
+	// encode formats the field as a value.
+	encode encoder
+	// remain is whether the field is a map
+	// of the columns not bound to other fields.
+	remain bool
+	// multi is whether the field is a slice scanned from every column
+	// matching its names, and decode and encode are for its elements.
+	multi bool
+}
+
+// match reports whether fi is scanned from a column named name.
+// The names of a multi field may contain a wildcard,
+// e.g. "item_*" matches "item_1" and "item_2".
+func (fi *fieldInfo) match(name string) bool {
+	for _, pattern := range fi.names {
+		if pattern == name || fi.multi && matchWildcard(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcard reports whether name matches pattern,
+// in which "*" stands for any text.
+func matchWildcard(pattern, name string) bool {
+	prefix, suffix, ok := strings.Cut(pattern, "*")
+	return ok && len(name) >= len(prefix)+len(suffix) &&
+		strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix)
+}
+
+// A decoder sets v from the string s.
+type decoder func(c *scanConfig, s string, v reflect.Value) error
+
+// scanConfig holds the Options that affect scanning.
+type scanConfig struct {
+	trueValues, falseValues []string
+	numbers                 NumberFormat
+	location                *time.Location
+}
+
+func (o *Options) scanConfig() *scanConfig {
+	return &scanConfig{
+		trueValues:  o.TrueValues,
+		falseValues: o.FalseValues,
+		numbers:     o.NumberFormat,
+		location:    o.Location,
+	}
+}
+
+// tagOptions are the options after the names in a csv field tag,
+// such as `csv:"name,key=value"`.
+type tagOptions map[string]string
+
+// parseTag splits a csv field tag into its names and options.
+func parseTag(tag string) ([]string, tagOptions) {
+	names, rest, _ := strings.Cut(tag, ",")
+	var opts tagOptions
+	if rest != "" {
+		opts = make(tagOptions)
+		for _, opt := range strings.Split(rest, ",") {
+			key, value, _ := strings.Cut(opt, "=")
+			opts[key] = value
+		}
+	}
+	return strings.Split(names, "|"), opts
+}
+
+// without returns opts without the option key.
+func (opts tagOptions) without(key string) tagOptions {
+	if _, ok := opts[key]; !ok {
+		return opts
+	}
+	opts2 := maps.Clone(opts)
+	delete(opts2, key)
+	return opts2
+}
+
+// separator returns the separator set by the tag option key, such as sep.
+// Since commas separate tag options, the separators of
+// numberFormatOption may be given by name.
+func (opts tagOptions) separator(key string) string {
+	switch v := opts[key]; v {
+	case "comma", "period", "dot", "space", "apostrophe":
+		return string(separatorOption(v))
+	default:
+		return v
+	}
+}
+
+// list returns the "|" separated values of the option key, if present.
+func (opts tagOptions) list(key string) []string {
+	if v, ok := opts[key]; ok {
+		return strings.Split(v, "|")
+	}
+	return nil
+}
+
+// binding connects a struct field to the column it is scanned from.
+type binding struct {
+	field, col int
+	decode     decoder
+	// cols, if not nil, are the columns scanned into a multi
+	// or remain field.
+	cols   []int
+	remain bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]fieldInfo
+
+// structFields returns the fields of struct type t that can be scanned into.
+// It returns nil if t is not a struct type.
+func structFields(t reflect.Type) []fieldInfo {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if fis, ok := fieldCache.Load(t); ok {
+		return fis.([]fieldInfo)
+	}
+	var fis []fieldInfo
+	for i, field := range fields(t) {
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("csv")
+		if tag == "" {
+			continue
+		}
+		names, opts := parseTag(tag)
+		if _, ok := opts["remain"]; ok {
+			if t := field.Type; t.Kind() == reflect.Map &&
+				t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String {
+				fis = append(fis, fieldInfo{index: i, remain: true})
+			}
+			continue
+		}
+		fi := fieldInfo{
+			index:  i,
+			names:  names,
+			decode: decoderFor(field.Type, opts),
+			encode: encoderFor(field.Type, opts),
+		}
+		if fi.decode == nil && field.Type.Kind() == reflect.Slice {
+			fi.decode = decoderFor(field.Type.Elem(), opts)
+			fi.encode = encoderFor(field.Type.Elem(), opts)
+			fi.multi = true
+		}
+		if fi.decode == nil {
+			continue
+		}
+		fis = append(fis, fi)
+	}
+	cached, _ := fieldCache.LoadOrStore(t, fis)
+	return cached.([]fieldInfo)
+}
+
+// bind returns the bindings of fis to the columns of r.
+func (r *Row) bind(fis []fieldInfo) []binding {
+	bindings := make([]binding, 0, len(fis))
+	bound := make([]bool, len(r.header))
+	for _, fi := range fis {
+		if fi.multi {
+			if cols := r.multiColumns(&fi); cols != nil {
+				bindings = append(bindings, binding{field: fi.index, decode: fi.decode, cols: cols})
+				for _, col := range cols {
+					bound[col] = true
+				}
+			}
+			continue
+		}
+		for _, name := range fi.names {
+			if col, ok := r.idx[name]; ok {
+				bindings = append(bindings, binding{field: fi.index, col: col, decode: fi.decode})
+				bound[col] = true
+				break
+			}
+		}
+	}
+	for _, fi := range fis {
+		if fi.remain {
+			remain := []int{}
+			for col, ok := range bound {
+				if !ok {
+					remain = append(remain, col)
+				}
+			}
+			bindings = append(bindings, binding{field: fi.index, cols: remain, remain: true})
+		}
+	}
+	return bindings
+}
+
+// multiColumns returns the columns matching the first name of the multi
+// field fi that matches any: every column of a repeated fieldname,
+// or every column matching a wildcard, in order.
+func (r *Row) multiColumns(fi *fieldInfo) []int {
+	for _, name := range fi.names {
+		if !strings.Contains(name, "*") {
+			if cols, ok := r.dups[name]; ok {
+				return cols
+			}
+			if col, ok := r.idx[name]; ok {
+				return []int{col}
+			}
+			continue
+		}
+		var cols []int
+		for col, field := range r.header {
+			if matchWildcard(name, field) {
+				cols = append(cols, col)
+			}
+		}
+		if cols != nil {
+			return cols
+		}
+	}
+	return nil
+}
+
+// defaultScanConfig is used for rows not read with Options, as from NewRow.
+var defaultScanConfig scanConfig
+
+// scan sets the fields of s from r by bindings.
+// If lenient is set, values that cannot be parsed are skipped.
+func (r *Row) scan(s reflect.Value, bindings []binding, lenient bool) error {
+	c := r.scanConf
+	if c == nil {
+		c = &defaultScanConfig
+	}
+	switch s.Kind() {
+	case reflect.Map:
+		r.scanMap(s, nil)
+		return nil
+	case reflect.Slice:
+		sl := reflect.MakeSlice(s.Type(), len(r.header), len(r.header))
+		for col := range r.header {
+			sl.Index(col).SetString(r.at(col))
+		}
+		s.Set(sl)
+		return nil
+	}
+	for _, b := range bindings {
+		switch {
+		case b.remain:
+			r.scanMap(s.Field(b.field), b.cols)
+		case b.cols != nil:
+			v := s.Field(b.field)
+			sl := reflect.MakeSlice(v.Type(), len(b.cols), len(b.cols))
+			for i, col := range b.cols {
+				err := r.decodeCol(c, b.decode, col, sl.Index(i))
+				switch {
+				case err != nil && !lenient:
+					return err
+				case err != nil && i < v.Len():
+					// Keep the element as it was.
+					sl.Index(i).Set(v.Index(i))
+				}
+			}
+			v.Set(sl)
+		default:
+			if err := r.decodeCol(c, b.decode, b.col, s.Field(b.field)); err != nil && !lenient {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeCol sets v from the value of column col with dec.
+func (r *Row) decodeCol(c *scanConfig, dec decoder, col int, v reflect.Value) error {
+	s := r.at(col)
+	if err := dec(c, s, v); err != nil {
+		return &ScanError{
+			Line:   r.line,
+			Row:    r.number,
+			Column: r.header[col],
+			Value:  s,
+			Err:    err,
+		}
+	}
+	return nil
+}
+
+// scanMap sets the map v to the values of cols by fieldname,
+// or to all the values of r if cols is nil.
+func (r *Row) scanMap(v reflect.Value, cols []int) {
+	t := v.Type()
+	n := len(r.header)
+	if cols != nil {
+		n = len(cols)
+	}
+	m := reflect.MakeMapWithSize(t, n)
+	for i := range n {
+		col := i
+		if cols != nil {
+			col = cols[i]
+		}
+		m.SetMapIndex(
+			reflect.ValueOf(r.header[col]).Convert(t.Key()),
+			reflect.ValueOf(r.at(col)).Convert(t.Elem()),
+		)
+	}
+	v.Set(m)
+}
+
+var (
+	textUnmarshalerType    = reflect.TypeFor[encoding.TextUnmarshaler]()
+	decimalUnmarshalerType = reflect.TypeFor[DecimalUnmarshaler]()
+	sqlScannerType         = reflect.TypeFor[sql.Scanner]()
+	bigIntType             = reflect.TypeFor[big.Int]()
+	bigFloatType           = reflect.TypeFor[big.Float]()
+	bigRatType             = reflect.TypeFor[big.Rat]()
+	durationType           = reflect.TypeFor[time.Duration]()
+	timeType               = reflect.TypeFor[time.Time]()
+)
+
+// decoderFor returns a decoder for values of type t
+// configured by opts, or nil if t is not supported.
+func decoderFor(t reflect.Type, opts tagOptions) decoder {
+	if _, ok := opts["json"]; ok {
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			// Allocate anew, since json.Unmarshal merges into maps
+			// and a map may be retained by a copy of the struct.
+			p := reflect.New(t)
+			if err := json.Unmarshal([]byte(s), p.Interface()); err != nil {
+				return err
+			}
+			v.Set(p.Elem())
+			return nil
+		}
+	}
+	if sep := opts.separator("sep"); sep != "" && t.Kind() == reflect.Slice {
+		elem := decoderFor(t.Elem(), opts.without("sep"))
+		if elem == nil {
+			return nil
+		}
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			items := strings.Split(s, sep)
+			sl := reflect.MakeSlice(t, len(items), len(items))
+			for i, item := range items {
+				if err := elem(c, strings.TrimSpace(item), sl.Index(i)); err != nil {
+					return err
+				}
+			}
+			v.Set(sl)
+			return nil
+		}
+	}
+	if sep := opts.separator("kv"); sep != "" && t.Kind() == reflect.Map {
+		kvsep := cmp.Or(opts.separator("kvsep"), "=")
+		elemOpts := opts.without("kv")
+		key, elem := decoderFor(t.Key(), elemOpts), decoderFor(t.Elem(), elemOpts)
+		if key == nil || elem == nil {
+			return nil
+		}
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			m := reflect.MakeMap(t)
+			k, e := reflect.New(t.Key()).Elem(), reflect.New(t.Elem()).Elem()
+			for _, item := range strings.Split(s, sep) {
+				if strings.TrimSpace(item) == "" {
+					continue
+				}
+				ks, es, ok := strings.Cut(item, kvsep)
+				if !ok {
+					return fmt.Errorf("csv: missing %q in %q", kvsep, item)
+				}
+				if err := key(c, strings.TrimSpace(ks), k); err != nil {
+					return err
+				}
+				if err := elem(c, strings.TrimSpace(es), e); err != nil {
+					return err
+				}
+				m.SetMapIndex(k, e)
+			}
+			v.Set(m)
+			return nil
+		}
+	}
+	if layouts := opts.list("layout"); t == timeType && layouts != nil {
+		var (
+			tz    *time.Location
+			tzErr error
+		)
+		if name, ok := opts["tz"]; ok {
+			tz, tzErr = time.LoadLocation(name)
+		}
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			if tzErr != nil {
+				return tzErr
+			}
+			loc := cmp.Or(tz, c.location, time.UTC)
+			tm, err := parseTime(s, layouts, loc)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(tm))
+			return nil
+		}
+	}
+	nf, nfSet := numberFormatOption(opts)
+	format := func(c *scanConfig) NumberFormat {
+		if nfSet {
+			return nf
+		}
+		return c.numbers
+	}
+	if t == bigIntType || t == bigFloatType || t == bigRatType ||
+		reflect.PointerTo(t).Implements(decimalUnmarshalerType) {
+		var prec uint64
+		if p, ok := opts["prec"]; ok {
+			prec, _ = strconv.ParseUint(p, 10, 32)
+		}
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			f := format(c)
+			s, percent := f.normalize(s)
+			if percent && f.Percent == PercentFraction {
+				if t == bigIntType {
+					return errPercentInt
+				}
+				s = percentFraction(s)
+			}
+			// Allocate anew, since big numbers share memory when copied.
+			p := reflect.New(t)
+			if err := unmarshalDecimal(p.Interface(), s, uint(prec)); err != nil {
+				return err
+			}
+			v.Set(p.Elem())
+			return nil
+		}
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			p := reflect.New(t)
+			if err := p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				return err
+			}
+			v.Set(p.Elem())
+			return nil
+		}
+	}
+	if reflect.PointerTo(t).Implements(sqlScannerType) {
+		// Such as sql.NullString, which is not Valid when empty.
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			p := reflect.New(t)
+			if err := p.Interface().(sql.Scanner).Scan(s); err != nil {
+				return err
+			}
+			v.Set(p.Elem())
+			return nil
+		}
+	}
+	if t == durationType {
+		syntax := opts["duration"]
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetInt(0)
+				return nil
+			}
+			d, err := parseDuration(s, syntax)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			v.SetString(s)
+			return nil
+		}
+	case reflect.Bool:
+		trues, falses := opts.list("true"), opts.list("false")
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetBool(false)
+				return nil
+			}
+			t, f := trues, falses
+			if t == nil && f == nil {
+				t, f = c.trueValues, c.falseValues
+			}
+			b, err := parseBool(s, t, f)
+			if err != nil {
+				return err
+			}
+			v.SetBool(b)
+			return nil
+		}
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetInt(0)
+				return nil
+			}
+			f := format(c)
+			s, percent := f.normalize(s)
+			if percent && f.Percent == PercentFraction {
+				return errPercentInt
+			}
+			n, err := strconv.ParseInt(s, 10, t.Bits())
+			if err != nil {
+				return err
+			}
+			v.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetUint(0)
+				return nil
+			}
+			f := format(c)
+			s, percent := f.normalize(s)
+			if percent && f.Percent == PercentFraction {
+				return errPercentInt
+			}
+			n, err := strconv.ParseUint(s, 10, t.Bits())
+			if err != nil {
+				return err
+			}
+			v.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetFloat(0)
+				return nil
+			}
+			f := format(c)
+			s, percent := f.normalize(s)
+			n, err := strconv.ParseFloat(s, t.Bits())
+			if err != nil {
+				return err
+			}
+			if percent && f.Percent == PercentFraction {
+				n /= 100
+			}
+			v.SetFloat(n)
+			return nil
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return nil
+		}
+		enc := opts["encoding"]
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			b, err := decodeBytes(s, enc)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(b)
+			return nil
+		}
+	case reflect.Pointer:
+		elem := decoderFor(t.Elem(), opts)
+		if elem == nil {
+			return nil
+		}
+		return func(c *scanConfig, s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			// Allocate anew, since the old value may be retained
+			// by a copy of the struct from an earlier row.
+			p := reflect.New(t.Elem())
+			if err := elem(c, s, p.Elem()); err != nil {
+				return err
+			}
+			v.Set(p)
+			return nil
+		}
+	}
+	return nil
+}
+
+func fields(t reflect.Type) iter.Seq2[int, reflect.StructField] {
+	return func(yield func(int, reflect.StructField) bool) {
+		for i := range t.NumField() {
+			if !yield(i, t.Field(i)) {
+				return
+			}
+		}
+	}
+}
+
+// parseBool reports whether s is one of trues or falses, ignoring case,
+// or else parses s with strconv.ParseBool.
+func parseBool(s string, trues, falses []string) (bool, error) {
+	for _, t := range trues {
+		if strings.EqualFold(s, t) {
+			return true, nil
+		}
+	}
+	for _, f := range falses {
+		if strings.EqualFold(s, f) {
+			return false, nil
+		}
+	}
+	return strconv.ParseBool(s)
+}
+
+// parseTime parses s with the first of layouts that fits it,
+// in loc if s has no time zone.
+// If none does, the error is from the first layout.
+func parseTime(s string, layouts []string, loc *time.Location) (time.Time, error) {
+	var first error
+	for _, layout := range layouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			return t, nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return time.Time{}, first
+}
+
+// decodeBytes decodes s in the binary-to-text encoding chosen
+// by the tag option encoding=base64, encoding=base64url, or encoding=hex.
+// Base64 is the default, and its padding is optional.
+func decodeBytes(s, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", "base64":
+		return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+	case "base64url":
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	case "hex":
+		return hex.DecodeString(s)
+	}
+	return nil, fmt.Errorf("csv: unknown encoding %q", encoding)
+}
+
+// parseDuration parses s as a duration in the syntax of
+// [time.ParseDuration] or of a clock, as chosen by the tag option
+// duration=go or duration=clock. By default, either is accepted.
+func parseDuration(s, syntax string) (time.Duration, error) {
+	if syntax == "clock" || syntax == "" && strings.Contains(s, ":") {
+		return parseClock(s)
+	}
+	return time.ParseDuration(s)
+}
+
+// parseClock parses a duration written as hours, minutes,
+// and optional seconds, like "1:30" or "-00:05:30.25".
+func parseClock(s string) (time.Duration, error) {
+	errSyntax := fmt.Errorf("csv: invalid clock duration %q", s)
+	sign, rest, _ := cutSign(s)
+	parts := strings.Split(rest, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, errSyntax
+	}
+	var d time.Duration
+	for i, part := range parts {
+		unit := [...]time.Duration{time.Hour, time.Minute, time.Second}[i]
+		if i == 2 {
+			secs, err := strconv.ParseFloat(part, 64)
+			if err != nil || part == "" || part[0] < '0' || part[0] > '9' || secs >= 60 {
+				return 0, errSyntax
+			}
+			d += time.Duration(secs * float64(time.Second))
+			continue
+		}
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || i > 0 && n >= 60 {
+			return 0, errSyntax
+		}
+		d += time.Duration(n) * unit
+	}
+	if sign == "-" {
+		d = -d
+	}
+	return d, nil
+}
@@ -0,0 +1,353 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ColumnType is the type of the values of a column in a [Schema].
+type ColumnType int8
+
+const (
+	// TypeString is any value.
+	TypeString ColumnType = iota
+	// TypeInt is a base 10 integer, as parsed by [strconv.ParseInt].
+	TypeInt
+	// TypeFloat is a number, as parsed by [strconv.ParseFloat].
+	TypeFloat
+	// TypeBool is a boolean, as parsed by [strconv.ParseBool].
+	TypeBool
+	// TypeTime is a date or time in the layout of [ColumnSchema.Layout].
+	TypeTime
+)
+
+var columnTypeNames = [...]string{
+	TypeString: "string",
+	TypeInt:    "int",
+	TypeFloat:  "float",
+	TypeBool:   "bool",
+	TypeTime:   "time",
+}
+
+func (t ColumnType) String() string {
+	if int(t) < len(columnTypeNames) {
+		return columnTypeNames[t]
+	}
+	return "ColumnType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// A Schema describes the columns of a CSV source.
+type Schema struct {
+	Columns []ColumnSchema
+}
+
+// A ColumnSchema describes one column of a [Schema].
+type ColumnSchema struct {
+	// Name is the fieldname of the column.
+	Name string
+	// Type is the type of the non-empty values of the column.
+	Type ColumnType
+	// Layout is the time layout of values of TypeTime.
+	Layout string
+	// Nullable reports whether values of the column may be empty.
+	Nullable bool
+	// Examples are some of the distinct values of the column.
+	Examples []string
+	// Pattern, if not empty, is a regular expression
+	// that must match the whole of each non-empty value.
+	Pattern string
+	// Enum, if not nil, lists the allowed non-empty values.
+	Enum []string
+}
+
+// timeLayouts are the layouts tried by InferSchema, in order of preference.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.DateTime,
+	time.DateOnly,
+	"2006/01/02",
+	"01/02/2006",
+	"02/01/2006",
+	"02-Jan-2006",
+	"Jan 2, 2006",
+	time.TimeOnly,
+}
+
+// maxExamples is the number of example values kept by InferSchema.
+const maxExamples = 3
+
+// columnGuess is the running state of InferSchema for one column.
+type columnGuess struct {
+	types   [TypeTime + 1]bool
+	layouts []string
+	seen    bool
+}
+
+func newColumnGuess() *columnGuess {
+	g := &columnGuess{layouts: slices.Clone(timeLayouts)}
+	for t := range g.types {
+		g.types[t] = true
+	}
+	return g
+}
+
+// add rules out the types that s does not fit.
+func (g *columnGuess) add(s string) {
+	g.seen = true
+	if g.types[TypeInt] {
+		_, err := strconv.ParseInt(s, 10, 64)
+		g.types[TypeInt] = err == nil
+	}
+	if g.types[TypeFloat] {
+		_, err := strconv.ParseFloat(s, 64)
+		g.types[TypeFloat] = err == nil
+	}
+	if g.types[TypeBool] {
+		_, err := strconv.ParseBool(s)
+		g.types[TypeBool] = err == nil
+	}
+	if g.types[TypeTime] {
+		g.layouts = slices.DeleteFunc(g.layouts, func(layout string) bool {
+			_, err := time.Parse(layout, s)
+			return err != nil
+		})
+		g.types[TypeTime] = len(g.layouts) > 0
+	}
+}
+
+// best returns the most specific type that fits every value.
+func (g *columnGuess) best() (ColumnType, string) {
+	if !g.seen {
+		return TypeString, ""
+	}
+	for _, t := range []ColumnType{TypeInt, TypeFloat, TypeBool, TypeTime} {
+		if g.types[t] {
+			if t == TypeTime {
+				return t, g.layouts[0]
+			}
+			return t, ""
+		}
+	}
+	return TypeString, ""
+}
+
+// InferSchema reads up to sampleRows rows of o, or every row
+// if sampleRows is not positive, and returns a Schema giving
+// the most specific type that fits the values of each column.
+// Integers are preferred to floats, floats to booleans,
+// and booleans to times. Times are tried against a list of common
+// layouts, such as RFC 3339 and "2006-01-02".
+// Blank rows are skipped.
+func InferSchema(o Options, sampleRows int) (Schema, error) {
+	if sampleRows > 0 && (o.Limit == 0 || sampleRows < o.Limit) {
+		o.Limit = sampleRows
+	}
+	r := NewReader(o)
+	var (
+		s       Schema
+		guesses []*columnGuess
+	)
+	for r.Next() {
+		row := r.Row()
+		if guesses == nil {
+			for _, name := range row.Header() {
+				s.Columns = append(s.Columns, ColumnSchema{Name: name})
+				guesses = append(guesses, newColumnGuess())
+			}
+		}
+		if row.Blank() {
+			continue
+		}
+		for i := range s.Columns {
+			c := &s.Columns[i]
+			v := row.at(i)
+			if v == "" {
+				c.Nullable = true
+				continue
+			}
+			guesses[i].add(v)
+			if len(c.Examples) < maxExamples && !slices.Contains(c.Examples, v) {
+				c.Examples = append(c.Examples, v)
+			}
+		}
+	}
+	if err := r.Err(); err != nil {
+		return Schema{}, err
+	}
+	if guesses == nil {
+		for _, name := range r.row.header {
+			s.Columns = append(s.Columns, ColumnSchema{Name: name, Nullable: true})
+		}
+	}
+	for i, g := range guesses {
+		c := &s.Columns[i]
+		c.Type, c.Layout = g.best()
+		if !g.seen {
+			c.Nullable = true
+		}
+	}
+	return s, nil
+}
+
+// Errors wrapped by [Violation.Err].
+var (
+	// ErrMissingColumn means a column of the Schema is not in the header.
+	ErrMissingColumn = errors.New("csv: missing column")
+	// ErrEmptyValue means a column that is not Nullable has an empty value.
+	ErrEmptyValue = errors.New("csv: empty value")
+	// ErrInvalidValue means a value does not fit its Type, Pattern, or Enum.
+	ErrInvalidValue = errors.New("csv: invalid value")
+)
+
+// A Violation is a place where a CSV source does not match a [Schema].
+type Violation struct {
+	// Line is the line where the row begins, as with [Row.Line].
+	// Row is the number of the row, as with [Row.Number].
+	// Both are 0 for a violation in the header.
+	Line, Row int
+	// Column is the fieldname of the column.
+	Column string
+	// Value is the value that does not match.
+	Value string
+	// Err wraps ErrMissingColumn, ErrEmptyValue, or ErrInvalidValue.
+	Err error
+}
+
+func (v Violation) Error() string {
+	if v.Row == 0 {
+		return fmt.Sprintf("header, column %q: %v", v.Column, v.Err)
+	}
+	return fmt.Sprintf("line %d, column %q: %v", v.Line, v.Column, v.Err)
+}
+
+func (v Violation) Unwrap() error {
+	return v.Err
+}
+
+// columnCheck is a ColumnSchema prepared for validation.
+type columnCheck struct {
+	*ColumnSchema
+	pattern *regexp.Regexp
+	col     int
+}
+
+// compile compiles the Pattern of c.
+func (c *columnCheck) compile() error {
+	if c.Pattern == "" {
+		return nil
+	}
+	var err error
+	c.pattern, err = regexp.Compile(`^(?:` + c.Pattern + `)$`)
+	return err
+}
+
+func (c *columnCheck) check(v string) error {
+	if v == "" {
+		if c.Nullable {
+			return nil
+		}
+		return ErrEmptyValue
+	}
+	var err error
+	switch c.Type {
+	case TypeInt:
+		_, err = strconv.ParseInt(v, 10, 64)
+	case TypeFloat:
+		_, err = strconv.ParseFloat(v, 64)
+	case TypeBool:
+		_, err = strconv.ParseBool(v)
+	case TypeTime:
+		_, err = time.Parse(c.Layout, v)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: not %s", ErrInvalidValue, c.Type)
+	}
+	if c.pattern != nil && !c.pattern.MatchString(v) {
+		return fmt.Errorf("%w: does not match %q", ErrInvalidValue, c.Pattern)
+	}
+	if c.Enum != nil && !slices.Contains(c.Enum, v) {
+		return fmt.Errorf("%w: not an allowed value", ErrInvalidValue)
+	}
+	return nil
+}
+
+// value checks v and returns it converted to the Go type of c.Type,
+// or nil if it is empty.
+func (c *columnCheck) value(v string) (any, error) {
+	if err := c.check(v); err != nil {
+		return nil, err
+	}
+	if v == "" {
+		return nil, nil
+	}
+	switch c.Type {
+	case TypeInt:
+		return strconv.ParseInt(v, 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(v, 64)
+	case TypeBool:
+		return strconv.ParseBool(v)
+	case TypeTime:
+		return time.Parse(c.Layout, v)
+	}
+	return v, nil
+}
+
+// Validate checks every row of o against s in a single pass
+// and returns the violations found, in order.
+// Columns of o not in s are not checked.
+// The returned error is for problems reading o or with s itself,
+// such as a Pattern that does not compile.
+// Blank rows are skipped.
+func Validate(o Options, s Schema) ([]Violation, error) {
+	var violations []Violation
+	checks := make([]columnCheck, 0, len(s.Columns))
+	r := NewReader(o)
+	r.started = true
+	if err := r.init(); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for i := range s.Columns {
+		c := columnCheck{ColumnSchema: &s.Columns[i]}
+		if err := c.compile(); err != nil {
+			return nil, err
+		}
+		var ok bool
+		if c.col, ok = r.row.idx[c.Name]; !ok {
+			violations = append(violations, Violation{
+				Column: c.Name,
+				Err:    ErrMissingColumn,
+			})
+			continue
+		}
+		checks = append(checks, c)
+	}
+	for r.Next() {
+		row := r.Row()
+		if row.Blank() {
+			continue
+		}
+		for i := range checks {
+			c := &checks[i]
+			v := row.at(c.col)
+			if err := c.check(v); err != nil {
+				violations = append(violations, Violation{
+					Line:   row.Line(),
+					Row:    row.Number(),
+					Column: c.Name,
+					Value:  v,
+					Err:    err,
+				})
+			}
+		}
+	}
+	return violations, r.Err()
+}
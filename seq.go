@@ -0,0 +1,76 @@
+package csv
+
+import "iter"
+
+// Filter returns a sequence of the rows of seq for which keep returns true.
+// Errors from seq are passed through.
+func Filter(seq iter.Seq2[*Row, error], keep func(*Row) bool) iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		for row, err := range seq {
+			if err != nil || keep(row) {
+				if !yield(row, err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// MapRows returns a sequence of the results of calling fn on each row of seq.
+// Errors from seq are passed through.
+// If fn returns an error, it is yielded and the sequence stops.
+func MapRows(seq iter.Seq2[*Row, error], fn func(*Row) (*Row, error)) iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		for row, err := range seq {
+			if err == nil {
+				row, err = fn(row)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+			}
+			if !yield(row, err) {
+				return
+			}
+		}
+	}
+}
+
+// Take returns a sequence of the first n rows of seq.
+// Errors from seq are passed through and do not count toward n.
+func Take(seq iter.Seq2[*Row, error], n int) iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		if n <= 0 {
+			return
+		}
+		taken := 0
+		for row, err := range seq {
+			if !yield(row, err) {
+				return
+			}
+			if err == nil {
+				taken++
+				if taken == n {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Drop returns a sequence of the rows of seq after the first n.
+// Errors from seq are passed through and do not count toward n.
+func Drop(seq iter.Seq2[*Row, error], n int) iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		dropped := 0
+		for row, err := range seq {
+			if err == nil && dropped < n {
+				dropped++
+				continue
+			}
+			if !yield(row, err) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// ShardWriter writes rows to a series of shards, starting a new shard
+// whenever the current one would exceed MaxRows rows or MaxBytes bytes.
+// The header is repeated at the start of each shard.
+// The exported fields must be set before the first call to WriteRow.
+type ShardWriter struct {
+	// Create must be set. It returns the destination of shard n,
+	// counting from 1. See [ShardFiles].
+	Create func(n int) (io.WriteCloser, error)
+	// MaxRows, if positive, is the most rows written to each shard,
+	// not counting the header.
+	MaxRows int
+	// MaxBytes, if positive, is the most bytes written to each shard,
+	// including the header. A shard may exceed MaxBytes only if it
+	// holds a single row that does not fit on its own.
+	MaxBytes int64
+	// Comma is the field delimiter, as in [Writer].
+	Comma rune
+	// UseCRLF is whether lines end with \r\n, as in [Writer].
+	UseCRLF bool
+	// FieldNames are the names of the columns to write, as in [Writer].
+	FieldNames []string
+
+	// enc encodes each row into buf before it is copied to a shard.
+	enc    Writer
+	buf    bytes.Buffer
+	header []byte
+	shard  int
+	dst    io.WriteCloser
+	bw     *bufio.Writer
+	rows   int
+	size   int64
+}
+
+// ShardFiles returns a function for [ShardWriter.Create]
+// that creates files named by formatting pattern with the shard number,
+// as with fmt.Sprintf(pattern, n). For example, "part-%04d.csv"
+// creates part-0001.csv, part-0002.csv, and so on.
+func ShardFiles(pattern string) func(n int) (io.WriteCloser, error) {
+	return func(n int) (io.WriteCloser, error) {
+		return os.Create(fmt.Sprintf(pattern, n))
+	}
+}
+
+// WriteRow writes the fields of row named by w.FieldNames
+// to the current shard, starting a new shard first if needed.
+func (w *ShardWriter) WriteRow(row *Row) error {
+	if w.header == nil {
+		if w.FieldNames == nil {
+			w.FieldNames = slices.Clone(row.Header())
+		}
+		if err := w.encodeHeader(); err != nil {
+			return err
+		}
+	}
+	w.buf.Reset()
+	if err := w.enc.WriteRow(row); err != nil {
+		return err
+	}
+	w.enc.cw.Flush()
+	if err := w.enc.cw.Error(); err != nil {
+		return err
+	}
+	if w.dst == nil ||
+		w.MaxRows > 0 && w.rows >= w.MaxRows ||
+		w.MaxBytes > 0 && w.rows > 0 && w.size+int64(w.buf.Len()) > w.MaxBytes {
+		if err := w.next(); err != nil {
+			return err
+		}
+	}
+	n, err := w.bw.Write(w.buf.Bytes())
+	w.size += int64(n)
+	w.rows++
+	return err
+}
+
+// encodeHeader sets up w.enc and saves the encoded header.
+func (w *ShardWriter) encodeHeader() error {
+	w.enc = Writer{
+		Writer:     &w.buf,
+		Comma:      w.Comma,
+		UseCRLF:    w.UseCRLF,
+		FieldNames: w.FieldNames,
+	}
+	if err := w.enc.writeHeader(); err != nil {
+		return err
+	}
+	w.enc.cw.Flush()
+	if err := w.enc.cw.Error(); err != nil {
+		return err
+	}
+	w.header = bytes.Clone(w.buf.Bytes())
+	return nil
+}
+
+// next closes the current shard, if any, and starts a new one.
+func (w *ShardWriter) next() error {
+	if err := w.closeShard(); err != nil {
+		return err
+	}
+	w.shard++
+	dst, err := w.Create(w.shard)
+	if err != nil {
+		return err
+	}
+	w.dst = dst
+	if w.bw == nil {
+		w.bw = bufio.NewWriter(dst)
+	} else {
+		w.bw.Reset(dst)
+	}
+	n, err := w.bw.Write(w.header)
+	w.rows, w.size = 0, int64(n)
+	return err
+}
+
+func (w *ShardWriter) closeShard() error {
+	if w.dst == nil {
+		return nil
+	}
+	dst := w.dst
+	w.dst = nil
+	err := w.bw.Flush()
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Close flushes and closes the current shard.
+// If no rows were written and FieldNames is set,
+// it first creates a shard holding only the header.
+func (w *ShardWriter) Close() error {
+	if w.shard == 0 && w.FieldNames != nil {
+		if err := w.encodeHeader(); err != nil {
+			return err
+		}
+		if err := w.next(); err != nil {
+			return err
+		}
+	}
+	return w.closeShard()
+}
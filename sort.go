@@ -0,0 +1,364 @@
+package csv
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// sortRunSize is the approximate number of bytes of fields
+// that [Sort] holds in memory before spilling them to a temporary file.
+const sortRunSize = 64 << 20
+
+// CompareMode controls how the values of a [SortKey] are compared.
+type CompareMode int8
+
+const (
+	// CompareStrings compares values bytewise.
+	CompareStrings CompareMode = iota
+	// CompareNumbers compares values as floating point numbers.
+	CompareNumbers
+	// CompareTimes compares values as times in the layout of [SortKey.Layout].
+	CompareTimes
+)
+
+// A SortKey is a column to sort by in [Sort].
+type SortKey struct {
+	// Column is the fieldname of the column.
+	Column string
+	// Compare controls how the values of Column are compared.
+	// With CompareNumbers and CompareTimes, empty values are less
+	// than all others, and other values that cannot be parsed
+	// are an error.
+	Compare CompareMode
+	// Layout is the time layout used by CompareTimes.
+	// If empty, time.RFC3339 is used.
+	Layout string
+	// If Descending is true, greater values sort first.
+	Descending bool
+}
+
+// sortValue is a parsed value of a SortKey.
+type sortValue struct {
+	s     string
+	f     float64
+	t     time.Time
+	empty bool
+}
+
+// sortItem is a record along with its parsed sort keys.
+type sortItem struct {
+	record []string
+	keys   []sortValue
+}
+
+// sorter parses and compares records by keys.
+type sorter struct {
+	keys []SortKey
+	cols []int
+}
+
+func newSorter(header []string, keys []SortKey) (*sorter, error) {
+	s := &sorter{keys: keys}
+	for _, key := range keys {
+		i := slices.Index(header, key.Column)
+		if i < 0 {
+			return nil, fmt.Errorf("csv: sort column %q not found", key.Column)
+		}
+		s.cols = append(s.cols, i)
+	}
+	return s, nil
+}
+
+func (s *sorter) item(record []string) (sortItem, error) {
+	item := sortItem{record: record, keys: make([]sortValue, len(s.keys))}
+	for i, key := range s.keys {
+		v := &item.keys[i]
+		v.s = record[s.cols[i]]
+		if key.Compare == CompareStrings {
+			continue
+		}
+		if v.s == "" {
+			v.empty = true
+			continue
+		}
+		var err error
+		if key.Compare == CompareNumbers {
+			v.f, err = strconv.ParseFloat(v.s, 64)
+		} else {
+			v.t, err = time.Parse(cmp.Or(key.Layout, time.RFC3339), v.s)
+		}
+		if err != nil {
+			return item, fmt.Errorf("csv: sort column %q: %w", key.Column, err)
+		}
+	}
+	return item, nil
+}
+
+func (s *sorter) compare(a, b sortItem) int {
+	for i, key := range s.keys {
+		x, y := a.keys[i], b.keys[i]
+		var c int
+		switch {
+		case key.Compare == CompareStrings:
+			c = cmp.Compare(x.s, y.s)
+		case x.empty && y.empty:
+		case x.empty:
+			c = -1
+		case y.empty:
+			c = 1
+		case key.Compare == CompareNumbers:
+			c = cmp.Compare(x.f, y.f)
+		default:
+			c = x.t.Compare(y.t)
+		}
+		if key.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// Sort writes the rows of o to w sorted by keys, and then closes w.
+// Rows with equal keys keep their order in o. Blank rows are dropped.
+//
+// Rows are sorted in memory in runs of about 64 MiB,
+// which are written to temporary files and then merged,
+// so Sort can sort sources much larger than memory.
+func Sort(o Options, w *Writer, keys ...SortKey) error {
+	return sortRuns(o, w, keys, sortRunSize)
+}
+
+// sortRuns is Sort with runs of runSize bytes.
+func sortRuns(o Options, w *Writer, keys []SortKey, runSize int) error {
+	r := NewReader(o)
+	var (
+		s     *sorter
+		items []sortItem
+		size  int
+		runs  []*os.File
+	)
+	defer func() {
+		for _, f := range runs {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	for r.Next() {
+		row := r.Row()
+		if s == nil {
+			var err error
+			if s, err = newSorter(row.Header(), keys); err != nil {
+				return err
+			}
+		}
+		if row.Blank() {
+			continue
+		}
+		row.materialize()
+		record := make([]string, len(row.header))
+		copy(record, row.row)
+		for _, field := range record {
+			size += len(field)
+		}
+		item, err := s.item(record)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", row.Line(), err)
+		}
+		items = append(items, item)
+		if size >= runSize {
+			slices.SortStableFunc(items, s.compare)
+			f, err := writeRun(items)
+			if f != nil {
+				runs = append(runs, f)
+			}
+			if err != nil {
+				return err
+			}
+			clear(items)
+			items, size = items[:0], 0
+		}
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	var out Row
+	out.index(r.row.header, KeepLastDuplicate)
+	write := func(record []string) error {
+		out.load(record)
+		return w.WriteRow(&out)
+	}
+	if w.FieldNames == nil && r.row.header != nil {
+		w.FieldNames = slices.Clone(r.row.header)
+	}
+	if s != nil {
+		slices.SortStableFunc(items, s.compare)
+	}
+	if len(runs) == 0 {
+		for _, item := range items {
+			if err := write(item.record); err != nil {
+				return err
+			}
+		}
+		return w.Close()
+	}
+	if len(items) > 0 {
+		f, err := writeRun(items)
+		if f != nil {
+			runs = append(runs, f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	items = nil
+	if err := mergeRuns(runs, s, write); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// writeRun writes the records of items to a new temporary file.
+// Each record is a count of fields followed by each field,
+// prefixed by its length.
+func writeRun(items []sortItem) (*os.File, error) {
+	f, err := os.CreateTemp("", "csv-sort-*")
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriter(f)
+	var buf []byte
+	for _, item := range items {
+		buf = binary.AppendUvarint(buf[:0], uint64(len(item.record)))
+		for _, field := range item.record {
+			buf = binary.AppendUvarint(buf, uint64(len(field)))
+			buf = append(buf, field...)
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return f, err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return f, err
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	return f, err
+}
+
+// readRun reads the next record written by writeRun from br.
+func readRun(br *bufio.Reader) ([]string, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	record := make([]string, n)
+	for i := range record {
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		field := make([]byte, size)
+		if _, err := io.ReadFull(br, field); err != nil {
+			return nil, noEOF(err)
+		}
+		record[i] = string(field)
+	}
+	return record, nil
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// runCursor is the next item of a run being merged.
+type runCursor struct {
+	br   *bufio.Reader
+	item sortItem
+	run  int
+}
+
+// runHeap orders runCursors by item, and then by run for stability.
+type runHeap struct {
+	s       *sorter
+	cursors []*runCursor
+}
+
+func (h *runHeap) Len() int { return len(h.cursors) }
+
+func (h *runHeap) Less(i, j int) bool {
+	a, b := h.cursors[i], h.cursors[j]
+	if c := h.s.compare(a.item, b.item); c != 0 {
+		return c < 0
+	}
+	return a.run < b.run
+}
+
+func (h *runHeap) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+
+func (h *runHeap) Push(x any) { h.cursors = append(h.cursors, x.(*runCursor)) }
+
+func (h *runHeap) Pop() any {
+	c := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return c
+}
+
+// advance reads the next item of c, reporting false at the end of the run.
+func (h *runHeap) advance(c *runCursor) (bool, error) {
+	record, err := readRun(c.br)
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	c.item, err = h.s.item(record)
+	return err == nil, err
+}
+
+// mergeRuns calls write with the records of runs in sorted order.
+func mergeRuns(runs []*os.File, s *sorter, write func([]string) error) error {
+	h := &runHeap{s: s}
+	for i, f := range runs {
+		c := &runCursor{br: bufio.NewReader(f), run: i}
+		ok, err := h.advance(c)
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.cursors = append(h.cursors, c)
+		}
+	}
+	heap.Init(h)
+	for h.Len() > 0 {
+		c := h.cursors[0]
+		if err := write(c.item.record); err != nil {
+			return err
+		}
+		ok, err := h.advance(c)
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,359 @@
+package csv
+
+import (
+	"cmp"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EncodeSQLRows writes the result set of rows to w,
+// with its column names as the header, and then closes rows and w.
+// If w.FieldNames is set, only those columns are written, as by
+// [Writer.WriteRow], and the second and later columns of a duplicated
+// name are selected by a numbered suffix as with [RenameDuplicates].
+// Otherwise every column is written, even if names are duplicated.
+// NULL values are written as w.Null.
+// Byte slices are written as strings if they are valid UTF-8,
+// and in base64 otherwise. Other values are formatted
+// as by [Writer.WriteStruct].
+func EncodeSQLRows(w *Writer, rows *sql.Rows) error {
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]any, len(cols))
+	dest := make([]any, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	encoders := make(map[reflect.Type]encoder)
+	record := make([]string, len(cols))
+	var row *Row
+	if w.FieldNames != nil {
+		row = new(Row)
+		row.index(cols, RenameDuplicates)
+	} else {
+		// Write the columns in order, so duplicated names are kept apart.
+		w.FieldNames = cols
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, val := range values {
+			switch val := val.(type) {
+			case nil:
+				record[i] = w.null(cols[i])
+			case []byte:
+				if utf8.Valid(val) {
+					record[i] = string(val)
+				} else {
+					record[i] = base64.StdEncoding.EncodeToString(val)
+				}
+			default:
+				if record[i], err = encodeValue(encoders, val); err != nil {
+					return err
+				}
+			}
+		}
+		if row == nil {
+			for i, val := range record {
+				record[i] = w.format(cols[i], val)
+			}
+			err = w.WriteRecord(record)
+		} else {
+			row.load(record)
+			err = w.WriteRow(row)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// encodeValue formats val with the encoder for its type,
+// which it caches in encoders.
+func encodeValue(encoders map[reflect.Type]encoder, val any) (string, error) {
+	t := reflect.TypeOf(val)
+	enc, ok := encoders[t]
+	if !ok {
+		enc = encoderFor(t, nil)
+		encoders[t] = enc
+	}
+	if enc == nil {
+		return fmt.Sprint(val), nil
+	}
+	v := reflect.New(t).Elem()
+	v.Set(reflect.ValueOf(val))
+	return enc(v)
+}
+
+// A Loader reads the rows of a CSV source as values for the columns
+// of a database table, converted to Go types by a [Schema].
+// Its Next, Values, and Err methods implement the CopyFromSource
+// interface of github.com/jackc/pgx, for bulk loading into PostgreSQL.
+// Alternatively, [Loader.Inserts] returns INSERT statements for the rows.
+type Loader struct {
+	r       *Reader
+	s       Schema
+	checks  []columnCheck
+	values  []any
+	err     error
+	started bool
+}
+
+// NewLoader returns a Loader for the rows of o, whose columns
+// are the columns of s. Columns of o are matched to those of s
+// by name, after [Options.Rename], and columns not in s are ignored.
+// If s has no columns, every column of o is a Nullable TypeString.
+// Empty values are nil, and other values are converted to
+// int64, float64, bool, time.Time, or string by their Type,
+// so they are valid [driver.Value] values.
+// Blank rows are skipped.
+// No input is read until the first call to [Loader.Next].
+func NewLoader(o Options, s Schema) *Loader {
+	return &Loader{r: NewReader(o), s: s}
+}
+
+// Columns returns the names of the database columns, in the order of Values.
+func (l *Loader) Columns() []string {
+	if !l.started {
+		l.init()
+	}
+	names := make([]string, len(l.s.Columns))
+	for i, c := range l.s.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// init reads the header of l.r and matches it to the columns of l.s.
+func (l *Loader) init() bool {
+	l.started = true
+	l.r.started = true
+	if err := l.r.init(); err != nil {
+		l.r.done = true
+		if err != io.EOF {
+			l.err = err
+		}
+		return false
+	}
+	if l.s.Columns == nil {
+		for _, name := range l.r.row.header {
+			l.s.Columns = append(l.s.Columns, ColumnSchema{Name: name, Nullable: true})
+		}
+	}
+	for i := range l.s.Columns {
+		c := columnCheck{ColumnSchema: &l.s.Columns[i]}
+		if err := c.compile(); err != nil {
+			l.err = err
+			return false
+		}
+		var ok bool
+		if c.col, ok = l.r.row.idx[c.Name]; !ok {
+			l.err = Violation{Column: c.Name, Err: ErrMissingColumn}
+			return false
+		}
+		l.checks = append(l.checks, c)
+	}
+	return true
+}
+
+// Next advances l to the next row, whose values are then
+// available from [Loader.Values]. It returns false when there are
+// no more rows, either by reaching the end of the input or an error.
+// A value that does not fit its column is a [Violation].
+func (l *Loader) Next() bool {
+	if l.err != nil || !l.started && !l.init() {
+		return false
+	}
+	for l.r.Next() {
+		row := l.r.Row()
+		if row.Blank() {
+			continue
+		}
+		l.values = l.values[:0]
+		for i := range l.checks {
+			c := &l.checks[i]
+			v := row.at(c.col)
+			val, err := c.value(v)
+			if err != nil {
+				l.err = Violation{
+					Line:   row.Line(),
+					Row:    row.Number(),
+					Column: c.Name,
+					Value:  v,
+					Err:    err,
+				}
+				return false
+			}
+			l.values = append(l.values, val)
+		}
+		return true
+	}
+	l.err = l.r.Err()
+	return false
+}
+
+// Values returns the values of the current row, in the order of Columns.
+// The slice is reused by the next call to Next.
+func (l *Loader) Values() ([]any, error) {
+	return l.values, nil
+}
+
+// Err returns the first error encountered by l.
+func (l *Loader) Err() error {
+	return l.err
+}
+
+// DriverRows adapts a [Loader] to [driver.Rows],
+// so CSV fixtures can stand in for query results,
+// as in tests or with tools that work with database drivers.
+// It also reports the types of its columns
+// with [driver.RowsColumnTypeScanType] and [driver.RowsColumnTypeNullable].
+type DriverRows struct {
+	l *Loader
+}
+
+var (
+	_ driver.RowsColumnTypeScanType = (*DriverRows)(nil)
+	_ driver.RowsColumnTypeNullable = (*DriverRows)(nil)
+)
+
+// NewDriverRows returns DriverRows for the rows of o,
+// with columns and values as for [NewLoader].
+func NewDriverRows(o Options, s Schema) *DriverRows {
+	return &DriverRows{NewLoader(o, s)}
+}
+
+// Columns returns the names of the columns.
+func (dr *DriverRows) Columns() []string {
+	return dr.l.Columns()
+}
+
+// Close does nothing. It does not close the Reader of the Options.
+func (dr *DriverRows) Close() error {
+	return nil
+}
+
+// Next sets dest to the values of the next row.
+// It returns io.EOF when there are no more rows.
+func (dr *DriverRows) Next(dest []driver.Value) error {
+	if !dr.l.Next() {
+		return cmp.Or(dr.l.Err(), io.EOF)
+	}
+	for i, v := range dr.l.values {
+		dest[i] = v
+	}
+	return nil
+}
+
+// ColumnTypeScanType returns the Go type of the values of column index.
+func (dr *DriverRows) ColumnTypeScanType(index int) reflect.Type {
+	switch dr.l.s.Columns[index].Type {
+	case TypeInt:
+		return reflect.TypeFor[int64]()
+	case TypeFloat:
+		return reflect.TypeFor[float64]()
+	case TypeBool:
+		return reflect.TypeFor[bool]()
+	case TypeTime:
+		return timeType
+	}
+	return reflect.TypeFor[string]()
+}
+
+// ColumnTypeNullable reports whether column index is Nullable.
+func (dr *DriverRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return dr.l.s.Columns[index].Nullable, true
+}
+
+// Placeholder is a style of parameter placeholders in SQL statements.
+type Placeholder int8
+
+const (
+	// QuestionMark placeholders are "?", as for MySQL and SQLite.
+	QuestionMark Placeholder = iota
+	// DollarNumber placeholders are "$1", "$2", and so on, as for PostgreSQL.
+	DollarNumber
+)
+
+// Inserts returns an iterator over parameterized INSERT statements
+// into table and their arguments, each inserting up to batchSize rows
+// of l. The table name is used as is, and column names are quoted
+// with double quotes, as standard SQL does.
+// Iteration stops at the first error, which Err reports.
+func (l *Loader) Inserts(table string, batchSize int, p Placeholder) iter.Seq2[string, []any] {
+	return func(yield func(string, []any) bool) {
+		batchSize = max(batchSize, 1)
+		full := ""
+		var args []any
+		n := 0
+		for l.Next() {
+			args = append(args, l.values...)
+			n++
+			if n < batchSize {
+				continue
+			}
+			if full == "" {
+				full = insertSQL(table, l.Columns(), n, p)
+			}
+			if !yield(full, args) {
+				return
+			}
+			args, n = nil, 0
+		}
+		if n > 0 && l.err == nil {
+			yield(insertSQL(table, l.Columns(), n, p), args)
+		}
+	}
+}
+
+// insertSQL returns an INSERT statement into table
+// with placeholders for n rows of columns.
+func insertSQL(table string, columns []string, n int, p Placeholder) string {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(table)
+	sb.WriteString(" (")
+	for i, name := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(`"` + strings.ReplaceAll(name, `"`, `""`) + `"`)
+	}
+	sb.WriteString(") VALUES ")
+	arg := 0
+	for row := range n {
+		if row > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for i := range columns {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			arg++
+			if p == DollarNumber {
+				sb.WriteString("$" + strconv.Itoa(arg))
+			} else {
+				sb.WriteByte('?')
+			}
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
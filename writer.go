@@ -0,0 +1,683 @@
+package csv
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrUnlistedField is returned for fields not in FieldNames
+// with [Writer.RejectUnlisted].
+var ErrUnlistedField = errors.New("csv: field not in FieldNames")
+
+var errNoComment = errors.New("csv: Writer.Comment is not set")
+
+var errAppendColumns = errors.New("csv: AppendFile cannot use Options.Columns, OmitColumns, or Rename")
+
+// Writer writes rows with named fields to a CSV file.
+// The exported fields must be set before the first call to a Write method.
+type Writer struct {
+	// Writer must be set
+	Writer io.Writer
+
+	// Comma is the field delimiter.
+	// It is set to comma (',') by default.
+	// To use 0x00 as the field separator, set it to -1
+	Comma rune
+	// Quote is the character that encloses fields containing Comma,
+	// newlines, or Quote itself, which is escaped by doubling it.
+	// It is set to double quote ('"') by default.
+	Quote rune
+	// If UseCRLF is true, lines end with \r\n instead of \n,
+	// as with encoding/csv.Writer.
+	UseCRLF bool
+	// If ByteOrderMark is true, the output begins with a UTF-8
+	// byte order mark, so Excel reads it as UTF-8.
+	ByteOrderMark bool
+	// If SepHint is true, the output begins with a line like "sep=;"
+	// naming Comma, so Excel splits columns on it whatever the locale.
+	// [Options] skips such a line when reading.
+	SepHint bool
+	// Comment, if not 0, is the comment character
+	// written by WriteComment and before the lines of Prologue.
+	Comment rune
+	// Prologue lines, such as metadata, are written before the header.
+	// If Comment is set, they are written as comments.
+	// Otherwise they are written as is, and can be skipped
+	// when reading with [Options.SkipRows].
+	Prologue []string
+	// FieldNames are the names of the columns to write, in order,
+	// whatever the order of the fields of the rows written.
+	// Fields of a row not in FieldNames are dropped.
+	// If FieldNames is left nil, it will be set to the header of
+	// the first row written, or to the sorted keys of the first map.
+	FieldNames []string
+	// If AppendUnlisted is true, the fields of the first row written
+	// that are not in FieldNames are added to the end of FieldNames
+	// in the order they appear in the row, or sorted for a map,
+	// so only the leading columns need to be listed.
+	AppendUnlisted bool
+	// If RejectUnlisted is true, WriteRow, WriteMap, and WriteStruct
+	// return an error wrapping [ErrUnlistedField] for a field
+	// not in FieldNames, instead of dropping it.
+	RejectUnlisted bool
+	// If NoHeader is true, no header is written.
+	NoHeader bool
+	// Rename maps FieldNames to the names written in the header.
+	// Fields are still looked up by FieldNames.
+	Rename map[string]string
+	// Quoting controls which fields are quoted.
+	// By default, only fields that need quotes are quoted.
+	Quoting QuoteMode
+	// ColumnQuoting, if not nil, overrides Quoting
+	// for the columns named by FieldNames.
+	ColumnQuoting map[string]QuoteMode
+	// Formatters, if not nil, format the values of the columns
+	// named by FieldNames when writing rows, maps, and structs,
+	// in place of the usual formatting. They are passed strings,
+	// except by WriteStruct, which passes the value of the field,
+	// with pointers followed; nil pointers are written as Null.
+	Formatters map[string]func(any) string
+	// Null is written by WriteStruct for nil pointers and for
+	// sql.Null values that are not Valid, such as "NULL", or `\N`
+	// for Postgres COPY. It is empty by default.
+	Null string
+	// ColumnNull, if not nil, overrides Null
+	// for the columns named by FieldNames.
+	ColumnNull map[string]string
+	// FlushRows, if positive, is how many rows are written
+	// between flushes to the underlying io.Writer.
+	FlushRows int
+	// FlushInterval, if positive, is the longest time rows may be held
+	// in the buffer. It is checked as each row is written.
+	FlushInterval time.Duration
+	// FormulaPrefix, if not empty, is prepended to fields that
+	// a spreadsheet could run as a formula, such as "=1+2" or "@SUM(A1)",
+	// to guard against formula injection. Such fields begin with
+	// =, +, -, @, tab, or carriage return, but are not numbers like "-1".
+	// A single quote ("'") or a tab ("\t") is usual.
+	FormulaPrefix string
+
+	cw          *recordWriter
+	wroteHeader bool
+	record      []string
+	// listed is the set of FieldNames, for RejectUnlisted.
+	listed map[string]bool
+	// closer, if not nil, is closed by Close.
+	closer io.Closer
+	// unflushed is the number of rows written since the last flush
+	// at flushed.
+	unflushed int
+	flushed   time.Time
+	// structType is the type last passed to WriteStruct,
+	// structColumns are the fields written for each of FieldNames,
+	// structElems are the number of columns written for each field,
+	// and structRemain is its remain field, if any.
+	structType    reflect.Type
+	structColumns []structColumn
+	structElems   map[*fieldInfo]int
+	structRemain  *fieldInfo
+}
+
+// structColumn is the field written to a column by WriteStruct.
+type structColumn struct {
+	// fi is the field, or nil if there is none.
+	fi *fieldInfo
+	// elem is the index of the element of a multi field.
+	elem int
+}
+
+func (w *Writer) init() {
+	if w.cw != nil {
+		return
+	}
+	w.cw = newRecordWriter(w.Writer)
+	w.flushed = time.Now()
+	w.cw.useCRLF = w.UseCRLF
+	w.cw.formulaPrefix = w.FormulaPrefix
+	if w.Comma == NULL {
+		w.cw.comma = 0x00
+	} else if w.Comma != 0 {
+		w.cw.comma = w.Comma
+	}
+	if w.Quote != 0 {
+		w.cw.quote = w.Quote
+	}
+	if w.Quoting != QuoteMinimal || w.ColumnQuoting != nil {
+		w.cw.quoting = w.quoting
+	}
+	// Errors writing to the buffer are reported by later writes.
+	if w.ByteOrderMark {
+		w.cw.w.Write(utf8BOM)
+	}
+	if w.SepHint {
+		w.cw.w.WriteString("sep=")
+		w.cw.w.WriteRune(w.cw.comma)
+		w.cw.endLine()
+	}
+	for _, line := range w.Prologue {
+		w.cw.writeLines(w.Comment, line)
+	}
+}
+
+// quoting returns the QuoteMode of column i.
+func (w *Writer) quoting(i int) QuoteMode {
+	if i < len(w.FieldNames) {
+		if mode, ok := w.ColumnQuoting[w.FieldNames[i]]; ok {
+			return mode
+		}
+	}
+	return w.Quoting
+}
+
+// writeHeader writes the header if it has not been written yet.
+func (w *Writer) writeHeader() error {
+	w.init()
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	if w.NoHeader {
+		return nil
+	}
+	header := w.FieldNames
+	if w.Rename != nil {
+		header = renameFields(header, w.Rename)
+	}
+	return w.cw.Write(header)
+}
+
+// WriteRow writes the fields of row named by w.FieldNames.
+// Fields missing from row are written as empty strings.
+func (w *Writer) WriteRow(row *Row) error {
+	if w.FieldNames == nil {
+		w.FieldNames = append([]string{}, row.Header()...)
+	} else if w.AppendUnlisted && !w.wroteHeader {
+		w.FieldNames = slices.Clip(w.FieldNames)
+		for _, name := range row.Header() {
+			if !slices.Contains(w.FieldNames, name) {
+				w.FieldNames = append(w.FieldNames, name)
+			}
+		}
+	}
+	if err := w.checkListed(slices.Values(row.Header())); err != nil {
+		return err
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.record = w.record[:0]
+	for _, name := range w.FieldNames {
+		w.record = append(w.record, w.format(name, row.Field(name)))
+	}
+	return w.write(w.record)
+}
+
+// format returns the value of the column name,
+// formatted by w.Formatters if it has a formatter for it.
+func (w *Writer) format(name, value string) string {
+	if f := w.Formatters[name]; f != nil {
+		return f(value)
+	}
+	return value
+}
+
+// null returns the value written for null in the column name.
+func (w *Writer) null(name string) string {
+	if null, ok := w.ColumnNull[name]; ok {
+		return null
+	}
+	return w.Null
+}
+
+// WriteMap writes the values of m named by w.FieldNames,
+// such as a map returned by [Options.ReadAll].
+// Keys missing from m are written as empty strings.
+func (w *Writer) WriteMap(m map[string]string) error {
+	if w.FieldNames == nil {
+		w.FieldNames = slices.Sorted(maps.Keys(m))
+	} else if w.AppendUnlisted && !w.wroteHeader {
+		var unlisted []string
+		for name := range m {
+			if !slices.Contains(w.FieldNames, name) {
+				unlisted = append(unlisted, name)
+			}
+		}
+		slices.Sort(unlisted)
+		w.FieldNames = append(slices.Clip(w.FieldNames), unlisted...)
+	}
+	if err := w.checkListed(maps.Keys(m)); err != nil {
+		return err
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.record = w.record[:0]
+	for _, name := range w.FieldNames {
+		w.record = append(w.record, w.format(name, m[name]))
+	}
+	return w.write(w.record)
+}
+
+// checkListed returns an error for any of names not in w.FieldNames
+// if w.RejectUnlisted is set.
+func (w *Writer) checkListed(names iter.Seq[string]) error {
+	if !w.RejectUnlisted {
+		return nil
+	}
+	if w.listed == nil {
+		w.listed = make(map[string]bool, len(w.FieldNames))
+		for _, name := range w.FieldNames {
+			w.listed[name] = true
+		}
+	}
+	for name := range names {
+		if !w.listed[name] {
+			return fmt.Errorf("%w: %q", ErrUnlistedField, name)
+		}
+	}
+	return nil
+}
+
+// checkListedStruct returns an error for any field of the struct rv
+// not in w.FieldNames if w.RejectUnlisted is set.
+func (w *Writer) checkListedStruct(rv reflect.Value) error {
+	if !w.RejectUnlisted {
+		return nil
+	}
+	fis := structFields(rv.Type())
+	for j := range fis {
+		fi := &fis[j]
+		n := w.structElems[fi]
+		switch {
+		case fi.remain:
+			m := rv.Field(fi.index)
+			if err := w.checkListed(func(yield func(string) bool) {
+				for iter := m.MapRange(); iter.Next(); {
+					if !yield(iter.Key().String()) {
+						return
+					}
+				}
+			}); err != nil {
+				return err
+			}
+		case fi.multi:
+			if rv.Field(fi.index).Len() > n {
+				name := strings.Replace(fi.names[0], "*", strconv.Itoa(n+1), 1)
+				return fmt.Errorf("%w: %q", ErrUnlistedField, name)
+			}
+		case n == 0:
+			return fmt.Errorf("%w: %q", ErrUnlistedField, fi.names[0])
+		}
+	}
+	return nil
+}
+
+// WriteStruct writes the fields of v, which must be a struct
+// or a pointer to one, formatted so that [Row.Scan] can scan them.
+// Fields are matched to columns by their csv field tags,
+// and list fields with the tag option sep are joined by sep.
+// If w.FieldNames is nil, it is set to the first name
+// in the tag of each field, in order, followed by the sorted keys
+// of the remain field of v, if it has one.
+// A slice field scanned from several columns gets a column
+// for each element of the slice in v, numbered from 1
+// if its name has a wildcard.
+// Columns with no matching field are written from the remain field,
+// or else as empty strings. Nil pointers and sql.Null values
+// that are not Valid are written as w.Null.
+func (w *Writer) WriteStruct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	} else {
+		// Make the fields addressable for their methods.
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		rv = p.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic("must write struct or pointer to struct")
+	}
+	if t := rv.Type(); t != w.structType {
+		fis := structFields(t)
+		w.structRemain = nil
+		for j := range fis {
+			if fis[j].remain {
+				w.structRemain = &fis[j]
+			}
+		}
+		if w.FieldNames == nil {
+			for _, fi := range fis {
+				switch {
+				case fi.multi:
+					name := fi.names[0]
+					for i := range rv.Field(fi.index).Len() {
+						w.FieldNames = append(w.FieldNames, strings.Replace(name, "*", strconv.Itoa(i+1), 1))
+					}
+				case !fi.remain:
+					w.FieldNames = append(w.FieldNames, fi.names[0])
+				}
+			}
+			if fi := w.structRemain; fi != nil {
+				var extra []string
+				for _, key := range rv.Field(fi.index).MapKeys() {
+					if name := key.String(); !slices.Contains(w.FieldNames, name) {
+						extra = append(extra, name)
+					}
+				}
+				slices.Sort(extra)
+				w.FieldNames = append(w.FieldNames, extra...)
+			}
+		}
+		w.structColumns = make([]structColumn, len(w.FieldNames))
+		elems := make(map[*fieldInfo]int)
+		for i, name := range w.FieldNames {
+			for j := range fis {
+				if fi := &fis[j]; fi.match(name) {
+					w.structColumns[i] = structColumn{fi, elems[fi]}
+					elems[fi]++
+					break
+				}
+			}
+		}
+		w.structElems = elems
+		w.structType = t
+	}
+	if err := w.checkListedStruct(rv); err != nil {
+		return err
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.record = w.record[:0]
+	for i, col := range w.structColumns {
+		s := ""
+		name := w.FieldNames[i]
+		fi := col.fi
+		var v reflect.Value
+		if fi != nil {
+			v = rv.Field(fi.index)
+			if fi.multi {
+				v = reflect.Value{}
+				if sl := rv.Field(fi.index); col.elem < sl.Len() {
+					v = sl.Index(col.elem)
+				}
+			}
+		}
+		f := w.Formatters[name]
+		switch {
+		case v.IsValid() && isNull(v):
+			s = w.null(name)
+		case v.IsValid() && f != nil:
+			for v.Kind() == reflect.Pointer {
+				v = v.Elem()
+			}
+			s = f(v.Interface())
+		case v.IsValid() && fi.encode != nil:
+			var err error
+			if s, err = fi.encode(v); err != nil {
+				return err
+			}
+		case fi == nil && w.structRemain != nil:
+			m := rv.Field(w.structRemain.index)
+			key := reflect.ValueOf(name).Convert(m.Type().Key())
+			if v := m.MapIndex(key); v.IsValid() {
+				s = w.format(name, v.String())
+			}
+		}
+		w.record = append(w.record, s)
+	}
+	return w.write(w.record)
+}
+
+// WriteRecord writes record as is, after writing the header if needed.
+// The fields of record should be in the order of w.FieldNames.
+// If w.FieldNames is nil, no header is written.
+func (w *Writer) WriteRecord(record []string) error {
+	if w.FieldNames == nil {
+		w.wroteHeader = true
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.write(record)
+}
+
+// WriteComment writes text as a comment line beginning with w.Comment,
+// or as several if text holds line breaks.
+// Comments written before the first row come before the header.
+func (w *Writer) WriteComment(text string) error {
+	if w.Comment == 0 {
+		return errNoComment
+	}
+	w.init()
+	return w.cw.writeLines(w.Comment, text)
+}
+
+// WriteAll writes the rows of seq and then closes w.
+// If seq yields an error, WriteAll returns it without closing w.
+func (w *Writer) WriteAll(seq iter.Seq2[*Row, error]) error {
+	for row, err := range seq {
+		if err != nil {
+			return err
+		}
+		if err = w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// WriteAllMaps writes the maps of seq with [Writer.WriteMap]
+// and then closes w.
+func (w *Writer) WriteAllMaps(seq iter.Seq[map[string]string]) error {
+	for m := range seq {
+		if err := w.WriteMap(m); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// WriteAllStructs writes the values of seq to w with [Writer.WriteStruct]
+// and then closes w.
+func WriteAllStructs[T any](w *Writer, seq iter.Seq[T]) error {
+	for v := range seq {
+		if err := w.WriteStruct(&v); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// write writes record, flushing afterward if w.FlushRows
+// or w.FlushInterval calls for it.
+func (w *Writer) write(record []string) error {
+	if err := w.cw.Write(record); err != nil {
+		return err
+	}
+	w.unflushed++
+	if w.FlushRows > 0 && w.unflushed >= w.FlushRows ||
+		w.FlushInterval > 0 && time.Since(w.flushed) >= w.FlushInterval {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes any buffered data to the underlying io.Writer
+// and reports any error from writing.
+// It does not write the header.
+func (w *Writer) Flush() error {
+	w.init()
+	w.cw.Flush()
+	w.unflushed, w.flushed = 0, time.Now()
+	return w.cw.Error()
+}
+
+// Close writes the header if no rows were written and FieldNames is set,
+// and then flushes any buffered data to the underlying io.Writer.
+// It does not close the underlying io.Writer,
+// unless w was returned by [AppendFile].
+func (w *Writer) Close() error {
+	if w.FieldNames != nil {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	err := w.Flush()
+	if w.closer != nil {
+		err = cmp.Or(err, w.closer.Close())
+		w.closer = nil
+	}
+	return err
+}
+
+// AppendFile opens the CSV file at path to append rows to it.
+// The header of the file is read with o, whose Reader is ignored,
+// and becomes the FieldNames of the returned Writer,
+// which writes fields in the column order of the file,
+// with the delimiter and quote the file was read with,
+// and sets RejectUnlisted, so unknown columns are an error.
+// Since the rows appended must have every column of the file,
+// o must not set Columns, OmitColumns, or Rename.
+// The header is not written again, unless the file is empty.
+// UseCRLF is set if the file ends with \r\n.
+// The caller must call [Writer.Close], which closes the file.
+func AppendFile(path string, o Options) (*Writer, error) {
+	if o.Columns != nil || o.OmitColumns != nil || o.Rename != nil {
+		return nil, errAppendColumns
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0)
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{
+		Writer:         f,
+		Comma:          o.Comma,
+		Quote:          o.Quote,
+		RejectUnlisted: true,
+		closer:         f,
+	}
+	if utf8.RuneCountInString(o.CommaString) > 1 {
+		f.Close()
+		return nil, fmt.Errorf("%w: %q", errInvalidDelim, o.CommaString)
+	}
+	if o.CommaString != "" {
+		w.Comma, _ = utf8.DecodeRuneInString(o.CommaString)
+	}
+	o.Reader, o.Records = f, nil
+	r := NewReader(o)
+	switch err := r.init(); err {
+	case io.EOF:
+		return w, nil
+	case nil:
+	default:
+		f.Close()
+		return nil, err
+	}
+	// Write with the delimiter found by reading, as from a sep= line.
+	w.Comma, _ = utf8.DecodeRune(r.cr.sep)
+	if w.Comma == 0x00 {
+		w.Comma = NULL
+	}
+	w.Quote = r.cr.quote
+	w.FieldNames = slices.Clone(r.row.header)
+	w.wroteHeader = true
+	if w.UseCRLF, err = endLine(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteFileAtomic writes rows to the file at path with w, whose Writer
+// is set to a temporary file in the same directory.
+// Only once all rows are written and synced to disk is the temporary
+// file renamed to path, so the file at path is never left partly written.
+// An existing file at path keeps its permissions;
+// a new file is created with mode 0666 less the umask, as by [os.Create].
+// w must not have been written to before.
+func WriteFileAtomic(path string, w Writer, rows iter.Seq2[*Row, error]) (err error) {
+	dir, base := filepath.Split(path)
+	existing, statErr := os.Stat(path)
+	f, err := createTemp(dir, "."+base+".", ".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	w.Writer = f
+	if err = w.WriteAll(rows); err != nil {
+		return err
+	}
+	if statErr == nil {
+		if err = f.Chmod(existing.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	// Sync the directory so the rename is durable.
+	// Not all systems support it, so errors are ignored.
+	if d, err := os.Open(filepath.Clean(dir + ".")); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// createTemp creates a new file in dir named prefix, a random number,
+// and suffix. Unlike [os.CreateTemp], it creates the file with mode 0666,
+// so the umask applies to it as it would to a file made by [os.Create].
+func createTemp(dir, prefix, suffix string) (*os.File, error) {
+	for range 10000 {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+suffix)
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if !os.IsExist(err) {
+			return f, err
+		}
+	}
+	return nil, &os.PathError{Op: "createtemp", Path: filepath.Join(dir, prefix+"*"+suffix), Err: os.ErrExist}
+}
+
+// endLine writes a newline to the end of f if f does not end with one.
+// It reports whether f ends with \r\n.
+func endLine(f *os.File) (crlf bool, err error) {
+	fi, err := f.Stat()
+	if err != nil || fi.Size() == 0 {
+		return false, err
+	}
+	last := make([]byte, min(2, fi.Size()))
+	if _, err := f.ReadAt(last, fi.Size()-int64(len(last))); err != nil {
+		return false, err
+	}
+	if last[len(last)-1] != '\n' {
+		_, err = f.Write([]byte{'\n'})
+		return false, err
+	}
+	return string(last) == "\r\n", nil
+}